@@ -0,0 +1,105 @@
+package httpparse
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// bodyReader returns a reader for the response body which undoes any
+// content coding listed in the Content-Encoding header (normally the
+// transport does this for us but not when the caller asked for a
+// particular encoding themselves) along with anything else the
+// options ask us to handle.
+func bodyReader(resp *http.Response, c *config) (io.Reader, error) {
+	var body io.Reader = resp.Body
+	if c.ctx != nil {
+		body = ctxReader{ctx: c.ctx, r: body}
+	}
+	if c.buffered {
+		if c.bufferSize > 0 {
+			body = bufio.NewReaderSize(body, c.bufferSize)
+		} else {
+			body = bufio.NewReader(body)
+		}
+	}
+	// Counting goes below the decompression so it is the bytes which
+	// came over the wire which get counted.
+	if c.bytesRead != nil {
+		*c.bytesRead = 0
+		body = countReader{r: body, n: c.bytesRead}
+	}
+	zipped := &compressedReader{r: body}
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	body, err := decompress(zipped, contentEncoding)
+	if err != nil {
+		return nil, err
+	}
+	if c.sniffGzip && !strings.Contains(strings.ToLower(contentEncoding), "gzip") {
+		if body, err = sniffGzip(body); err != nil {
+			return nil, err
+		}
+	}
+	// Only now do we know whether the body is compressed, which is
+	// when the compressed read limit starts to count.
+	if _, ok := body.(decompressErrReader); ok {
+		zipped.limit = c.zipLimit
+		c.compressed = zipped
+	}
+	return body, nil
+}
+
+// compressedReader reads a compressed response body, stopping once it
+// has read more than limit bytes (like io.LimitedReader does) if limit
+// is positive.
+type compressedReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (c *compressedReader) Read(b []byte) (int, error) {
+	if c.limit > 0 {
+		left := c.limit + 1 - c.n
+		if left <= 0 {
+			return 0, io.EOF
+		}
+		if int64(len(b)) > left {
+			b = b[:left]
+		}
+	}
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// exceeded reports whether more than limit bytes have been read.
+func (c *compressedReader) exceeded() bool {
+	return c.limit > 0 && c.n > c.limit
+}
+
+// countReader adds the number of bytes read from r to *n.
+type countReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c countReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	*c.n += int64(n)
+	return n, err
+}
+
+// drainAndClose reads and discards whatever is left of body before
+// closing it. net/http only puts a keep-alive connection back in the
+// pool once the response body has been read to the end, so without
+// this a body with trailing bytes or one we stopped reading early
+// (like after a status code mismatch) costs us the connection. No more
+// than maxBytes are drained though, a server which never stops sending
+// is not worth keeping a connection to.
+func drainAndClose(body io.ReadCloser, maxBytes int64) {
+	io.CopyN(ioutil.Discard, body, maxBytes)
+	body.Close()
+}
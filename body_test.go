@@ -0,0 +1,279 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestBufferSize tests that buffering the response body does not
+// change what gets parsed.
+func TestBufferSize(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		opts []httpparse.Option
+	}{
+		{
+			name: "default buffer size",
+			body: `{"value_one":"hello there","value_two":42}`,
+			opts: []httpparse.Option{httpparse.WithBufferSize(0)},
+		},
+		{
+			name: "tiny buffer",
+			body: `{"value_one":"hello there","value_two":42}`,
+			opts: []httpparse.Option{httpparse.WithBufferSize(1)},
+		},
+		{
+			name: "big buffer with gzip sniffing",
+			body: gzipped(`{"value_one":"hello there","value_two":42}`),
+			opts: []httpparse.Option{httpparse.WithBufferSize(1 << 16), httpparse.WithGzipSniffing()},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			if err := httpparse.JSON(resp, []int{200}, &data, test.opts...); err != nil {
+				t.Fatalf("got a non-nil error: %v", err)
+			}
+			if got, want := data, (structuredJSON{ValueOne: "hello there", ValueTwo: 42}); got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// countingReader counts how often it gets read from.
+type countingReader struct {
+	r     io.Reader
+	reads int
+}
+
+func (c *countingReader) Read(b []byte) (int, error) {
+	c.reads++
+	return c.r.Read(b)
+}
+
+func (c *countingReader) Close() error {
+	return nil
+}
+
+// BenchmarkJSONBufferSize shows the effect of the buffer size on
+// decoding a large body. The reads/op metric is the number of reads
+// made against the underlying response body.
+func BenchmarkJSONBufferSize(b *testing.B) {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := 0; i < 50000; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{"value_one":"record number %d","value_two":%d}`, i, i)
+	}
+	sb.WriteString("]")
+	body := sb.String()
+	for _, size := range []int{-1, 4 << 10, 64 << 10, 1 << 20} {
+		name := fmt.Sprintf("%dKB", size>>10)
+		var opts []httpparse.Option
+		if size < 0 {
+			name = "unbuffered"
+		} else {
+			opts = append(opts, httpparse.WithBufferSize(size))
+		}
+		b.Run(name, func(b *testing.B) {
+			reads := 0
+			for i := 0; i < b.N; i++ {
+				r := &countingReader{r: strings.NewReader(body)}
+				var data []structuredJSON
+				if err := httpparse.JSON(&http.Response{StatusCode: 200, Body: r}, []int{200}, &data, opts...); err != nil {
+					b.Fatal(err)
+				}
+				reads += r.reads
+			}
+			b.ReportMetric(float64(reads)/float64(b.N), "reads/op")
+		})
+	}
+}
+
+// drainRecorder remembers how much of the body was left when it got
+// closed.
+type drainRecorder struct {
+	*strings.Reader
+	leftAtClose int
+	closed      bool
+}
+
+func (d *drainRecorder) Close() error {
+	d.leftAtClose = d.Len()
+	d.closed = true
+	return nil
+}
+
+// TestDrainBody tests that the remaining response body is drained,
+// but not endlessly, before it is closed.
+func TestDrainBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		parse    func(resp *http.Response) error
+		wantLeft int
+	}{
+		{
+			name:   "JSON with trailing bytes",
+			status: 200,
+			body:   `{"value_one":"hi"}` + strings.Repeat(" ", 5000),
+			parse: func(resp *http.Response) error {
+				var data structuredJSON
+				return httpparse.JSON(resp, []int{200}, &data)
+			},
+			wantLeft: 0,
+		},
+		{
+			name:   "JSON with a status mismatch and a large body",
+			status: 500,
+			body:   strings.Repeat("z", 1<<20+5000),
+			parse: func(resp *http.Response) error {
+				var data structuredJSON
+				return httpparse.JSON(resp, []int{200}, &data)
+			},
+			wantLeft: 0,
+		},
+		{
+			name:   "draining is bounded by the read limit",
+			status: 500,
+			body:   strings.Repeat("z", 1<<20+5000),
+			parse: func(resp *http.Response) error {
+				var data structuredJSON
+				return httpparse.JSON(resp, []int{200}, &data, httpparse.WithReadLimit(1000))
+			},
+			wantLeft: 3999,
+		},
+		{
+			name:   "RawBody exceeding the read limit",
+			status: 200,
+			body:   strings.Repeat("z", 5000),
+			parse: func(resp *http.Response) error {
+				_, err := httpparse.RawBody(resp, []int{200}, httpparse.WithReadLimit(1000))
+				return err
+			},
+			wantLeft: 2999,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := &drainRecorder{Reader: strings.NewReader(test.body)}
+			test.parse(&http.Response{StatusCode: test.status, Body: body})
+
+			if !body.closed {
+				t.Fatal("the response body was not closed")
+			}
+			if got, want := body.leftAtClose, test.wantLeft; got != want {
+				t.Errorf("got %d bytes left in the body when it was closed, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+// TestBytesRead tests that the number of bytes read from the body is
+// reported.
+func TestBytesRead(t *testing.T) {
+	tests := []struct {
+		name  string
+		resp  *http.Response
+		parse func(resp *http.Response, n *int64) error
+		wantN int64
+	}{
+		{
+			name: "RawBodyN",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			parse: func(resp *http.Response, n *int64) error {
+				var err error
+				_, *n, err = httpparse.RawBodyN(resp, []int{200})
+				return err
+			},
+			wantN: 11,
+		},
+		{
+			name: "RawBodyN exceeding the read limit",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			parse: func(resp *http.Response, n *int64) error {
+				var err error
+				_, *n, err = httpparse.RawBodyN(resp, []int{200}, httpparse.WithReadLimit(5))
+				if err == nil {
+					return errors.New("wanted the read limit to be exceeded")
+				}
+				return nil
+			},
+			wantN: 6,
+		},
+		{
+			name: "JSON",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			parse: func(resp *http.Response, n *int64) error {
+				var data structuredJSON
+				return httpparse.JSON(resp, []int{200}, &data, httpparse.WithBytesRead(n))
+			},
+			wantN: 18,
+		},
+		{
+			name: "JSON with a status mismatch",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("oh no")),
+			},
+			parse: func(resp *http.Response, n *int64) error {
+				var data structuredJSON
+				if err := httpparse.JSON(resp, []int{200}, &data, httpparse.WithBytesRead(n)); err == nil {
+					return errors.New("wanted a status mismatch")
+				}
+				return nil
+			},
+			wantN: 5,
+		},
+		{
+			name: "compressed bytes are counted",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {"gzip"}},
+				Body:       ioutil.NopCloser(strings.NewReader(gzipped(strings.Repeat("a", 1000)))),
+			},
+			parse: func(resp *http.Response, n *int64) error {
+				var err error
+				_, *n, err = httpparse.RawBodyN(resp, []int{200})
+				return err
+			},
+			wantN: int64(len(gzipped(strings.Repeat("a", 1000)))),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var n int64
+			if err := test.parse(test.resp, &n); err != nil {
+				t.Fatalf("got a non-nil error: %v", err)
+			}
+			if got, want := n, test.wantN; got != want {
+				t.Errorf("got %d bytes read, wanted %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Bool is a bool which can be unmarshalled from the inconsistent
+// boolean representations some APIs return. Use it in place of bool
+// for the fields of a struct that such an API returns. The accepted
+// representations are exactly:
+//
+//	true:  true, 1, "true", "yes", "1"
+//	false: false, 0, "false", "no", "0"
+//
+// Strings are matched case insensitively. A JSON null leaves the
+// value untouched, just like it would for a regular bool, and anything
+// else is an error.
+type Bool bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Bool) UnmarshalJSON(data []byte) error {
+	s := string(bytes.TrimSpace(data))
+	if s == "null" {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		s = strings.ToLower(s)
+	}
+	switch s {
+	case "true", "yes", "1":
+		*b = true
+	case "false", "no", "0":
+		*b = false
+	default:
+		return fmt.Errorf("cannot interpret %s as a boolean", data)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestBool tests that the lenient boolean type accepts the documented
+// representations and rejects everything else.
+func TestBool(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		start   httpparse.Bool
+		want    httpparse.Bool
+		wantErr string
+	}{
+		{name: "true literal", json: `true`, want: true},
+		{name: "false literal", json: `false`, start: true, want: false},
+		{name: "one", json: `1`, want: true},
+		{name: "zero", json: `0`, start: true, want: false},
+		{name: "true string", json: `"true"`, want: true},
+		{name: "false string", json: `"false"`, start: true, want: false},
+		{name: "yes string", json: `"yes"`, want: true},
+		{name: "no string", json: `"no"`, start: true, want: false},
+		{name: "one string", json: `"1"`, want: true},
+		{name: "zero string", json: `"0"`, start: true, want: false},
+		{name: "strings are case insensitive", json: `"YeS"`, want: true},
+		{name: "null leaves the value alone", json: `null`, start: true, want: true},
+		{name: "other numbers are rejected", json: `2`, wantErr: "cannot interpret 2 as a boolean"},
+		{name: "other strings are rejected", json: `"maybe"`, wantErr: `cannot interpret "maybe" as a boolean`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data struct {
+				Enabled httpparse.Bool `json:"enabled"`
+			}
+			data.Enabled = test.start
+			err := json.Unmarshal([]byte(`{"enabled":`+test.json+`}`), &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data.Enabled, test.want; got != want {
+				t.Errorf("got %v, wanted %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package httpparse
+
+import "net/http"
+
+// Breaker is a circuit breaker which Do and DoWithRetry consult before
+// every attempt at a request and tell about how it went, see
+// WithBreaker. Its methods may be called from multiple goroutines at
+// once.
+type Breaker interface {
+	// Allow is called before every attempt. Returning an error
+	// preempts the attempt and that error is returned as is, without
+	// any more retries.
+	Allow() error
+	// Record is called after every attempt which was allowed with the
+	// status code of the response or, when there was none, the error
+	// performing the request.
+	Record(statusCode int, err error)
+}
+
+// allow asks the breaker set with WithBreaker whether to make an
+// attempt.
+func (c *config) allow() error {
+	if c.breaker == nil {
+		return nil
+	}
+	return c.breaker.Allow()
+}
+
+// record tells the breaker set with WithBreaker how an attempt went.
+func (c *config) record(resp *http.Response, err error) {
+	if c.breaker == nil {
+		return
+	}
+	if err != nil {
+		c.breaker.Record(0, err)
+		return
+	}
+	c.breaker.Record(resp.StatusCode, nil)
+}
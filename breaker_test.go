@@ -0,0 +1,118 @@
+package httpparse_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+var errOpen = errors.New("circuit open")
+
+// countingBreaker opens after a number of failures in a row.
+type countingBreaker struct {
+	mu       sync.Mutex
+	maxFails int
+	fails    int
+	records  []string
+}
+
+func (b *countingBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fails >= b.maxFails {
+		return errOpen
+	}
+	return nil
+}
+
+func (b *countingBreaker) Record(statusCode int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records = append(b.records, fmt.Sprintf("%d %v", statusCode, err))
+	if err != nil || statusCode >= 500 {
+		b.fails++
+	} else {
+		b.fails = 0
+	}
+}
+
+// TestBreaker tests that the breaker observes every attempt and can
+// preempt them.
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name         string
+		results      []interface{}
+		retry        bool
+		wantAttempts int
+		wantRecords  []string
+		wantErr      error
+	}{
+		{
+			name:         "success is recorded",
+			results:      []interface{}{200},
+			wantAttempts: 1,
+			wantRecords:  []string{"200 <nil>"},
+		},
+		{
+			name:         "retries are recorded",
+			results:      []interface{}{503, 200},
+			retry:        true,
+			wantAttempts: 2,
+			wantRecords:  []string{"503 <nil>", "200 <nil>"},
+		},
+		{
+			name:         "open breaker preempts the retries",
+			results:      []interface{}{errors.New("connection refused"), 503, 200},
+			retry:        true,
+			wantAttempts: 2,
+			wantRecords:  []string{`0 Get "http://example.com/things": connection refused`, "503 <nil>"},
+			wantErr:      errOpen,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				result := test.results[attempts-1]
+				if err, ok := result.(error); ok {
+					return nil, err
+				}
+				return &http.Response{
+					StatusCode: result.(int),
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+					Request:    req,
+				}, nil
+			})}
+			b := &countingBreaker{maxFails: 2}
+			opts := []httpparse.Option{httpparse.WithBreaker(b), httpparse.WithRetryBackoff(time.Millisecond, time.Millisecond)}
+			req, _ := http.NewRequest("GET", "http://example.com/things", nil)
+			var data structuredJSON
+			var err error
+			if test.retry {
+				err = httpparse.DoWithRetry(context.Background(), client, req, []int{200}, &data, opts...)
+			} else {
+				err = httpparse.Do(context.Background(), client, req, []int{200}, &data, opts...)
+			}
+
+			if got, want := err, test.wantErr; got != want {
+				t.Errorf("got error %v, wanted %v", got, want)
+			}
+			if got, want := attempts, test.wantAttempts; got != want {
+				t.Errorf("got %d attempts, wanted %d", got, want)
+			}
+			if got, want := b.records, test.wantRecords; !reflect.DeepEqual(got, want) {
+				t.Errorf("got records %q, wanted %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package brotliparse teaches httpparse to decompress response bodies
+// with a Content-Encoding of br, which is what many CDNs serve. Import
+// it for its side effect:
+//
+//	import _ "github.com/lag13/httpparse/brotliparse"
+//
+// It lives apart from httpparse so that only those who need Brotli
+// depend on a Brotli library.
+package brotliparse
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/lag13/httpparse"
+)
+
+func init() {
+	httpparse.RegisterDecompressor("br", Decompress)
+}
+
+// Decompress is a httpparse.DecompressFunc for Brotli, it uses
+// github.com/andybalholm/brotli.
+func Decompress(r io.Reader) (io.Reader, error) {
+	return brotli.NewReader(r), nil
+}
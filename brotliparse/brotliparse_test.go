@@ -0,0 +1,80 @@
+package brotliparse_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/lag13/httpparse"
+	_ "github.com/lag13/httpparse/brotliparse"
+)
+
+func compress(s string) string {
+	var buf bytes.Buffer
+	w := brotli.NewWriter(&buf)
+	w.Write([]byte(s))
+	w.Close()
+	return buf.String()
+}
+
+// TestBrotli tests that response bodies with a br Content-Encoding are
+// decompressed.
+func TestBrotli(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            string
+		opts            []httpparse.Option
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:            "br",
+			contentEncoding: "br",
+			body:            compress("hello there"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "coding is case insensitive",
+			contentEncoding: "BR",
+			body:            compress("hello there"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "corrupt body",
+			contentEncoding: "br",
+			body:            "not really brotli",
+			wantErr:         "reading response body: decompressing br response body: ",
+		},
+		{
+			name:            "limit applies to the decompressed body",
+			contentEncoding: "br",
+			body:            compress(strings.Repeat("a", 1000)),
+			opts:            []httpparse.Option{httpparse.WithReadLimit(100)},
+			wantErr:         "The response body decompressed into more than the limit of 100 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			body, err := httpparse.RawBody(resp, []int{200}, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
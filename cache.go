@@ -0,0 +1,198 @@
+package httpparse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CacheKey derives a stable key for storing resp in a cache. It is
+// made of the request's method and URL plus the values the request had
+// for each of the headers named by the response's Vary header, since
+// those are the headers which affected what the server sent back. Vary
+// names are matched case insensitively and their order does not
+// matter.
+//
+// A response with "Vary: *" varies on things beyond the request
+// headers and can never be served from a cache so, just like a
+// response without a request to key on, it gets the empty string.
+func CacheKey(resp *http.Response) string {
+	req := resp.Request
+	if req == nil || req.URL == nil {
+		return ""
+	}
+	seen := map[string]bool{}
+	var names []string
+	for _, vary := range resp.Header[textproto.CanonicalMIMEHeaderKey("Vary")] {
+		for _, name := range strings.Split(vary, ",") {
+			name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return ""
+			}
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var key strings.Builder
+	key.WriteString(method + " " + req.URL.String())
+	for _, name := range names {
+		key.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ","))
+	}
+	return key.String()
+}
+
+// CacheEntry is what a Cache remembers about a response.
+type CacheEntry struct {
+	// ETag and LastModified are the response's validators, the
+	// values of its ETag and Last-Modified headers.
+	ETag         string
+	LastModified string
+	// Vary is the response's Vary header. When it names any headers
+	// the entry under the request's method and URL alone holds nothing
+	// but Vary, which says what key (see CacheKey) the actual entry for
+	// a request is stored under.
+	Vary string
+	// Body is the response body, decompressed and in UTF-8.
+	Body []byte
+}
+
+// CacheStore is where a Cache keeps its entries, e.g. in memory or in
+// Redis. Its methods may be called from multiple goroutines at once.
+type CacheStore interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+// Cache performs GET requests conditionally: once a response with an
+// ETag or Last-Modified header has been parsed, the next request for
+// the same URL is sent with If-None-Match or If-Modified-Since and a
+// 304 Not Modified answer is served from the cache. That saves the
+// server the work of sending, and the client the work of reading, a
+// body which did not change. A Cache is safe for concurrent use.
+type Cache struct {
+	client *http.Client
+	store  CacheStore
+}
+
+// NewCache returns a Cache which performs requests with client
+// (http.DefaultClient if it is nil) and keeps its entries in store. A
+// nil store means entries are kept in memory, forever, which is only
+// fine for a small and fixed set of URLs.
+func NewCache(client *http.Client, store CacheStore) *Cache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if store == nil {
+		store = &memoryStore{entries: map[string]CacheEntry{}}
+	}
+	return &Cache{client: client, store: store}
+}
+
+// JSON performs req and parses the response just like Do does, hedging
+// and circuit breaker included, with a 200 being the wanted status
+// code, except that a 304 Not Modified is decoded from the cached body
+// when req was sent conditionally. Only GET requests are cached, others
+// are simply performed. The cache is keyed with CacheKey so a response
+// with a Vary header is only served to requests which had the same
+// values for the headers it names.
+func (c *Cache) JSON(ctx context.Context, req *http.Request, v interface{}, opts ...Option) error {
+	if req.Method != "" && req.Method != http.MethodGet {
+		return Do(ctx, c.client, req, []int{http.StatusOK}, v, opts...)
+	}
+	key := CacheKey(&http.Response{Request: req})
+	entry, cached := c.store.Get(key)
+	if cached && entry.Vary != "" {
+		key = CacheKey(&http.Response{Header: http.Header{"Vary": {entry.Vary}}, Request: req})
+		entry, cached = c.store.Get(key)
+	}
+	orig := req
+	req = req.Clone(ctx)
+	if cached {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+	conf := newConfig(opts)
+	if err := conf.allow(); err != nil {
+		return err
+	}
+	resp, err := conf.send(ctx, c.client, req)
+	if err != nil {
+		return fmt.Errorf("performing request: %v", err)
+	}
+	if cached && resp.StatusCode == http.StatusNotModified {
+		drainAndClose(resp.Body, conf.readLimit)
+		// The cached body was checked and transcoded when it was first
+		// parsed so all that is left is decoding it.
+		return decodeLimited(bytes.NewReader(entry.Body), conf, func(r io.Reader, c *config) error {
+			return decodeJSON(r, v, c)
+		})
+	}
+	// The body is kept exactly as it got decoded, which takes teeing it
+	// alongside any tee of the caller.
+	var body bytes.Buffer
+	keep := func(c *config) {
+		if c.tee != nil {
+			c.tee = io.MultiWriter(c.tee, &body)
+		} else {
+			c.tee = &body
+		}
+	}
+	if err := JSONContext(ctx, resp, []int{http.StatusOK}, v, append(opts[:len(opts):len(opts)], keep)...); err != nil {
+		return err
+	}
+	entry = CacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Vary:         strings.Join(resp.Header.Values("Vary"), ", "),
+		Body:         body.Bytes(),
+	}
+	if resp.StatusCode != http.StatusOK || entry.ETag == "" && entry.LastModified == "" {
+		return nil
+	}
+	key = CacheKey(&http.Response{Header: resp.Header, Request: orig})
+	if key == "" {
+		return nil
+	}
+	if entry.Vary != "" {
+		c.store.Set(CacheKey(&http.Response{Request: orig}), CacheEntry{Vary: entry.Vary})
+	}
+	c.store.Set(key, entry)
+	return nil
+}
+
+// memoryStore is the CacheStore used when none is given.
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]CacheEntry
+}
+
+func (m *memoryStore) Get(key string) (CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[key]
+	return entry, ok
+}
+
+func (m *memoryStore) Set(key string, entry CacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key] = entry
+}
@@ -0,0 +1,287 @@
+package httpparse_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestCacheKey tests that the cache key is built from the request and
+// the headers named by Vary.
+func TestCacheKey(t *testing.T) {
+	u, _ := url.Parse("https://example.com/users?page=2")
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		wantKey string
+	}{
+		{
+			name:    "no request",
+			resp:    &http.Response{},
+			wantKey: "",
+		},
+		{
+			name: "no Vary header",
+			resp: &http.Response{
+				Request: &http.Request{URL: u, Header: http.Header{"Accept": {"application/json"}}},
+			},
+			wantKey: "GET https://example.com/users?page=2",
+		},
+		{
+			name: "Vary headers are sorted and deduplicated",
+			resp: &http.Response{
+				Header: http.Header{"Vary": {"accept-encoding, Accept", "ACCEPT"}},
+				Request: &http.Request{
+					Method: "HEAD",
+					URL:    u,
+					Header: http.Header{
+						"Accept":          {"application/json", "text/plain"},
+						"Accept-Encoding": {"gzip"},
+						"User-Agent":      {"test"},
+					},
+				},
+			},
+			wantKey: "HEAD https://example.com/users?page=2\nAccept: application/json,text/plain\nAccept-Encoding: gzip",
+		},
+		{
+			name: "Vary header missing from the request",
+			resp: &http.Response{
+				Header:  http.Header{"Vary": {"Authorization"}},
+				Request: &http.Request{Method: "GET", URL: u},
+			},
+			wantKey: "GET https://example.com/users?page=2\nAuthorization: ",
+		},
+		{
+			name: "Vary star is uncacheable",
+			resp: &http.Response{
+				Header:  http.Header{"Vary": {"Accept, *"}},
+				Request: &http.Request{Method: "GET", URL: u},
+			},
+			wantKey: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got, want := httpparse.CacheKey(test.resp), test.wantKey; got != want {
+				t.Errorf("got key %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCache tests that responses are cached and revalidated with
+// conditional requests.
+func TestCache(t *testing.T) {
+	version := "v1"
+	var conditions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		switch r.URL.Path {
+		case "/etag":
+			etag := `"` + version + `"`
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			fmt.Fprintf(w, `{"value_one":%q}`, version)
+		case "/last-modified":
+			if r.Header.Get("If-Modified-Since") != "" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			fmt.Fprint(w, `{"value_two":42}`)
+		case "/uncacheable":
+			fmt.Fprint(w, `{"value_two":1}`)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name           string
+		path           string
+		newVersion     string
+		wantData       structuredJSON
+		wantConditions []string
+	}{
+		{
+			name:           "ETag",
+			path:           "/etag",
+			wantData:       structuredJSON{ValueOne: "v1"},
+			wantConditions: []string{"|", `"v1"|`, `"v1"|`},
+		},
+		{
+			name:           "ETag which changes",
+			path:           "/etag",
+			newVersion:     "v2",
+			wantData:       structuredJSON{ValueOne: "v2"},
+			wantConditions: []string{"|", `"v1"|`, `"v2"|`},
+		},
+		{
+			name:           "Last-Modified",
+			path:           "/last-modified",
+			wantData:       structuredJSON{ValueTwo: 42},
+			wantConditions: []string{"|", "|Wed, 21 Oct 2015 07:28:00 GMT", "|Wed, 21 Oct 2015 07:28:00 GMT"},
+		},
+		{
+			name:           "no validators",
+			path:           "/uncacheable",
+			wantData:       structuredJSON{ValueTwo: 1},
+			wantConditions: []string{"|", "|", "|"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version = "v1"
+			conditions = nil
+			cache := httpparse.NewCache(nil, nil)
+			for i := 0; i < 3; i++ {
+				if i == 1 && test.newVersion != "" {
+					version = test.newVersion
+				}
+				req, _ := http.NewRequest("GET", srv.URL+test.path, nil)
+				var data structuredJSON
+				if err := cache.JSON(context.Background(), req, &data); err != nil {
+					t.Fatalf("request %d got a non-nil error: %v", i+1, err)
+				}
+				if i == 2 {
+					if got, want := data, test.wantData; got != want {
+						t.Errorf("got data %+v, wanted %+v", got, want)
+					}
+				}
+			}
+			if got, want := conditions, test.wantConditions; !reflect.DeepEqual(got, want) {
+				t.Errorf("got conditions %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCacheVary tests that a response with a Vary header is only served
+// from the cache to requests with the same values for the headers it
+// names.
+func TestCacheVary(t *testing.T) {
+	var conditions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-None-Match"))
+		etag := `"` + r.Header.Get("Accept") + `"`
+		w.Header().Set("Vary", "Accept")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprintf(w, `{"value_one":%q}`, r.Header.Get("Accept"))
+	}))
+	defer srv.Close()
+
+	cache := httpparse.NewCache(nil, nil)
+	var got []string
+	for _, accept := range []string{"a", "b", "a", "b"} {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		req.Header.Set("Accept", accept)
+		var data structuredJSON
+		if err := cache.JSON(context.Background(), req, &data); err != nil {
+			t.Fatalf("got a non-nil error: %v", err)
+		}
+		got = append(got, data.ValueOne)
+	}
+
+	if want := []string{"a", "b", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got data %q, wanted %q", got, want)
+	}
+	if got, want := conditions, []string{"", "", `"a"`, `"b"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got conditions %q, wanted %q", got, want)
+	}
+}
+
+// TestCacheDecoding tests that fresh and cached bodies are decoded just
+// like JSON decodes them.
+func TestCacheDecoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []httpparse.Option
+		wantData    structuredJSON
+		wantErr     string
+	}{
+		{
+			name:        "charset is transcoded",
+			contentType: "application/json; charset=iso-8859-1",
+			body:        "{\"value_one\":\"caf\xe9\"}",
+			wantData:    structuredJSON{ValueOne: "caf\u00e9"},
+		},
+		{
+			name:        "content type is checked",
+			contentType: "text/html",
+			body:        `{"value_one":"a"}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantErr:     "text/html",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") != "" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("Content-Type", test.contentType)
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, test.body)
+			}))
+			defer srv.Close()
+
+			cache := httpparse.NewCache(nil, nil)
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest("GET", srv.URL, nil)
+				var data structuredJSON
+				err := cache.JSON(context.Background(), req, &data, test.opts...)
+
+				if test.wantErr == "" && err != nil {
+					t.Errorf("request %d got a non-nil error: %v", i+1, err)
+				} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+					t.Errorf("request %d got error message: %s, wanted message to contain the string: %s", i+1, got, want)
+				}
+				if got, want := data, test.wantData; got != want {
+					t.Errorf("request %d got data %+v, wanted %+v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestCacheBreaker tests that cached requests go through the circuit
+// breaker like any other.
+func TestCacheBreaker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	b := &countingBreaker{maxFails: 1}
+	cache := httpparse.NewCache(nil, nil)
+	var errs []string
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		var data structuredJSON
+		err := cache.JSON(context.Background(), req, &data, httpparse.WithBreaker(b))
+		errs = append(errs, fmt.Sprintf("%v", err))
+	}
+
+	if got, want := b.records, []string{"503 <nil>"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got breaker records %q, wanted %q", got, want)
+	}
+	if got, want := errs[1], errOpen.Error(); got != want {
+		t.Errorf("got second error %s, wanted %s", got, want)
+	}
+}
@@ -0,0 +1,29 @@
+// Package cborparse parses http responses who's body contains CBOR. It
+// lives apart from httpparse so that only those who need CBOR depend on
+// a CBOR library.
+package cborparse
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/lag13/httpparse"
+)
+
+// CBOR parses a http response who's body contains CBOR (e.g.
+// application/cbor) and closes the response body. It works just like
+// httpparse.JSON does, including the status code checks, read limit,
+// error messages and options, except that it uses
+// github.com/fxamacker/cbor. Struct fields are matched using their cbor
+// tags, falling back to their json tags.
+func CBOR(resp *http.Response, wantStatuses []int, v interface{}, opts ...httpparse.Option) error {
+	opts = append(opts[:len(opts):len(opts)], httpparse.WithExpectedStatuses(wantStatuses...), httpparse.WithDecoder(Decode))
+	return httpparse.NewParser(opts...).Decode(resp, v)
+}
+
+// Decode is a httpparse.DecodeFunc for CBOR, for use with
+// httpparse.WithDecoder.
+func Decode(r io.Reader, v interface{}) error {
+	return cbor.NewDecoder(r).Decode(v)
+}
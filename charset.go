@@ -0,0 +1,119 @@
+package httpparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// The byte order marks which stripBOM knows about.
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16BEBOM = []byte{0xfe, 0xff}
+	utf16LEBOM = []byte{0xff, 0xfe}
+)
+
+// charsetDecoder returns a decoder which transcodes a body in the
+// charset of the response's Content-Type into UTF-8. It returns nil
+// when there is nothing to transcode, i.e. there is no charset or it
+// already is UTF-8.
+func charsetDecoder(resp *http.Response) (*encoding.Decoder, error) {
+	charset := contentTypeCharset(resp)
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8":
+		return nil, nil
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q in Content-Type", charset)
+	}
+	return enc.NewDecoder(), nil
+}
+
+// contentTypeCharset returns the charset named by the response's
+// Content-Type, "" when it does not name one.
+func contentTypeCharset(resp *http.Response) string {
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// stripBOM drops the byte order mark which some servers (mostly those
+// running on Windows) put at the start of a body and which JSON does
+// not allow. A UTF-16 one means the rest of the body is UTF-16 too so
+// it gets transcoded to UTF-8 as well. The BOM hook, if there is one,
+// is told about any BOM found.
+func (c *config) stripBOM(br *bufio.Reader) *bufio.Reader {
+	b, _ := br.Peek(len(utf8BOM))
+	var bom string
+	switch {
+	case bytes.HasPrefix(b, utf8BOM):
+		br.Discard(len(utf8BOM))
+		c.foundBOM("UTF-8")
+		return br
+	case bytes.HasPrefix(b, utf16BEBOM):
+		bom = "UTF-16BE"
+	case bytes.HasPrefix(b, utf16LEBOM):
+		bom = "UTF-16LE"
+	default:
+		return br
+	}
+	c.foundBOM(bom)
+	// ExpectBOM makes the decoder consume the BOM and pick the byte
+	// order it says, the BigEndian is only a fallback.
+	dec := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder()
+	return bufio.NewReader(transform.NewReader(br, dec))
+}
+
+// foundBOM calls the BOM hook, if there is one.
+func (c *config) foundBOM(bom string) {
+	if c.bomHook != nil {
+		c.bomHook(bom)
+	}
+}
+
+// xmlDecoder returns a decoder for the XML in r. encoding/xml only
+// understands UTF-8 so a document whose XML declaration says it is in
+// another encoding gets transcoded as it is read, unless the
+// Content-Type named a charset and r was already transcoded according
+// to it (the Content-Type wins over the declaration, see RFC 7303).
+func xmlDecoder(r io.Reader, transcoded bool) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if transcoded {
+			return input, nil
+		}
+		enc, err := htmlindex.Get(label)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported encoding %q in XML declaration", label)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	return dec
+}
+
+// transcodeBytes turns body into UTF-8 according to the charset of the
+// response's Content-Type.
+func transcodeBytes(body []byte, resp *http.Response) ([]byte, error) {
+	dec, err := charsetDecoder(resp)
+	if err != nil || dec == nil {
+		return body, err
+	}
+	body, err = dec.Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("transcoding response body to UTF-8: %v", err)
+	}
+	return body, nil
+}
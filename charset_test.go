@@ -0,0 +1,249 @@
+package httpparse_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestCharset tests that bodies in charsets other than UTF-8 are
+// transcoded to UTF-8.
+func TestCharset(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantText    string
+		wantErr     string
+	}{
+		{
+			name:        "no charset",
+			contentType: "application/json",
+			body:        `{"value_one":"café"}`,
+			wantText:    "café",
+		},
+		{
+			name:        "UTF-8",
+			contentType: "application/json; charset=UTF-8",
+			body:        `{"value_one":"café"}`,
+			wantText:    "café",
+		},
+		{
+			name:        "Latin-1",
+			contentType: "application/json; charset=ISO-8859-1",
+			body:        "{\"value_one\":\"caf\xe9\"}",
+			wantText:    "café",
+		},
+		{
+			name:        "Windows-1252",
+			contentType: `application/json; charset="windows-1252"`,
+			body:        "{\"value_one\":\"\x80 caf\xe9\"}",
+			wantText:    "€ café",
+		},
+		{
+			name:        "unsupported charset",
+			contentType: "application/json; charset=klingon",
+			body:        `{"value_one":"café"}`,
+			wantErr:     `unsupported charset "klingon" in Content-Type`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := func() *http.Response {
+				return &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": {test.contentType}},
+					Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+				}
+			}
+
+			var data structuredJSON
+			err := httpparse.JSON(resp(), []int{200}, &data)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("JSON: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("JSON: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data.ValueOne, test.wantText; got != want {
+				t.Errorf("JSON: got %q, wanted %q", got, want)
+			}
+
+			text, err := httpparse.Text(resp(), []int{200})
+			if test.wantErr == "" && err != nil {
+				t.Errorf("Text: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("Text: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if test.wantErr == "" && !strings.Contains(text, test.wantText) {
+				t.Errorf("Text: got %q, wanted it to contain %q", text, test.wantText)
+			}
+		})
+	}
+}
+
+// TestCharsetXML tests that XML bodies in charsets other than UTF-8 are
+// transcoded to UTF-8, whether the charset is in the Content-Type or
+// the XML declaration.
+func TestCharsetXML(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantText    string
+		wantErr     string
+	}{
+		{
+			name:        "no charset",
+			contentType: "application/xml",
+			body:        `<doc><value_one>café</value_one></doc>`,
+			wantText:    "café",
+		},
+		{
+			name:        "Latin-1 Content-Type",
+			contentType: "application/xml; charset=ISO-8859-1",
+			body:        "<doc><value_one>caf\xe9</value_one></doc>",
+			wantText:    "café",
+		},
+		{
+			name:        "Latin-1 declaration",
+			contentType: "application/xml",
+			body:        "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><doc><value_one>caf\xe9</value_one></doc>",
+			wantText:    "café",
+		},
+		{
+			name:        "Content-Type wins over the declaration",
+			contentType: "text/xml; charset=Shift_JIS",
+			body:        "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><doc><value_one>\x93\xfa\x96{</value_one></doc>",
+			wantText:    "日本",
+		},
+		{
+			name:        "UTF-8 Content-Type wins over the declaration",
+			contentType: "application/xml; charset=utf-8",
+			body:        `<?xml version="1.0" encoding="ISO-8859-1"?><doc><value_one>café</value_one></doc>`,
+			wantText:    "café",
+		},
+		{
+			name:        "unsupported declared encoding",
+			contentType: "application/xml",
+			body:        `<?xml version="1.0" encoding="klingon"?><doc><value_one>café</value_one></doc>`,
+			wantErr:     `unsupported encoding "klingon" in XML declaration`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := func() *http.Response {
+				return &http.Response{
+					StatusCode: 200,
+					Header:     http.Header{"Content-Type": {test.contentType}},
+					Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+				}
+			}
+
+			var data structuredXML
+			err := httpparse.XML(resp(), []int{200}, &data)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("XML: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("XML: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data.ValueOne, test.wantText; got != want {
+				t.Errorf("XML: got %q, wanted %q", got, want)
+			}
+
+			data = structuredXML{}
+			err = httpparse.DecodeByContentType(resp(), []int{200}, &data)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("DecodeByContentType: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("DecodeByContentType: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data.ValueOne, test.wantText; got != want {
+				t.Errorf("DecodeByContentType: got %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// utf16Body returns s encoded as UTF-16 with a leading byte order mark.
+func utf16Body(s string, bigEndian bool) string {
+	var buf bytes.Buffer
+	for _, u := range utf16.Encode([]rune("\ufeff" + s)) {
+		if bigEndian {
+			buf.WriteByte(byte(u >> 8))
+			buf.WriteByte(byte(u))
+		} else {
+			buf.WriteByte(byte(u))
+			buf.WriteByte(byte(u >> 8))
+		}
+	}
+	return buf.String()
+}
+
+// TestBOM tests that a byte order mark at the start of a JSON body is
+// stripped.
+func TestBOM(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantData structuredJSON
+		wantBOM  string
+	}{
+		{
+			name:     "no BOM",
+			body:     `{"value_one":"café"}`,
+			wantData: structuredJSON{ValueOne: "café"},
+			wantBOM:  "",
+		},
+		{
+			name:     "UTF-8 BOM",
+			body:     "\xef\xbb\xbf" + `{"value_one":"café"}`,
+			wantData: structuredJSON{ValueOne: "café"},
+			wantBOM:  "UTF-8",
+		},
+		{
+			name:     "UTF-8 BOM before an empty body",
+			body:     "\xef\xbb\xbf",
+			wantData: structuredJSON{},
+			wantBOM:  "UTF-8",
+		},
+		{
+			name:     "UTF-16BE BOM",
+			body:     utf16Body(`{"value_one":"café"}`, true),
+			wantData: structuredJSON{ValueOne: "café"},
+			wantBOM:  "UTF-16BE",
+		},
+		{
+			name:     "UTF-16LE BOM",
+			body:     utf16Body(`{"value_one":"café"}`, false),
+			wantData: structuredJSON{ValueOne: "café"},
+			wantBOM:  "UTF-16LE",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var bom string
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, httpparse.WithBOMHook(func(b string) { bom = b }))
+
+			if err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if got, want := bom, test.wantBOM; got != want {
+				t.Errorf("got BOM %q, wanted %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package httpparse
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ConnInfo is what can be known about the connection a response was
+// received on. It is meant as an aid when investigating the
+// performance of a client's connection pool.
+type ConnInfo struct {
+	// Traced reports whether the request was made with a context
+	// returned by TraceConnection. Reused, WasIdle, IdleTime,
+	// LocalAddr and RemoteAddr are only filled in when it is true
+	// since there is no other way to learn them after the fact.
+	Traced     bool
+	Reused     bool
+	WasIdle    bool
+	IdleTime   time.Duration
+	LocalAddr  net.Addr
+	RemoteAddr net.Addr
+
+	// Proto is the protocol the response was received with, e.g.
+	// "HTTP/1.1".
+	Proto string
+
+	// The remaining fields come from resp.TLS and are only filled
+	// in when the connection used TLS.
+	TLS                bool
+	TLSVersion         uint16
+	TLSResumed         bool
+	NegotiatedProtocol string
+	ServerName         string
+}
+
+type connRecorderKey struct{}
+
+// connRecorder remembers the connection handed out for a request.
+type connRecorder struct {
+	mu   sync.Mutex
+	got  bool
+	info httptrace.GotConnInfo
+}
+
+// TraceConnection returns a copy of ctx which records the connection
+// used by any request made with it. It composes with whatever
+// httptrace.ClientTrace is already in ctx. Use it when making a
+// request and ConnectionInfo will be able to report whether the
+// connection was reused and what its addresses were.
+func TraceConnection(ctx context.Context) context.Context {
+	rec := &connRecorder{}
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			rec.got = true
+			rec.info = info
+		},
+	})
+	return context.WithValue(ctx, connRecorderKey{}, rec)
+}
+
+// ConnectionInfo returns what is knowable about the connection resp
+// was received on. Only the protocol and TLS details can be gleaned
+// from the response itself, everything else requires the request to
+// have been made with a context from TraceConnection. Even then some
+// limitations apply: if the transport retried the request only the
+// last connection is reported and, for HTTP/2, "reused" means that a
+// stream was multiplexed onto an existing connection.
+func ConnectionInfo(resp *http.Response) ConnInfo {
+	info := ConnInfo{Proto: resp.Proto}
+	if resp.TLS != nil {
+		info.TLS = true
+		info.TLSVersion = resp.TLS.Version
+		info.TLSResumed = resp.TLS.DidResume
+		info.NegotiatedProtocol = resp.TLS.NegotiatedProtocol
+		info.ServerName = resp.TLS.ServerName
+	}
+	if resp.Request == nil {
+		return info
+	}
+	rec, ok := resp.Request.Context().Value(connRecorderKey{}).(*connRecorder)
+	if !ok {
+		return info
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if !rec.got {
+		return info
+	}
+	info.Traced = true
+	info.Reused = rec.info.Reused
+	info.WasIdle = rec.info.WasIdle
+	info.IdleTime = rec.info.IdleTime
+	if rec.info.Conn != nil {
+		info.LocalAddr = rec.info.Conn.LocalAddr()
+		info.RemoteAddr = rec.info.Conn.RemoteAddr()
+	}
+	return info
+}
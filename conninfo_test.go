@@ -0,0 +1,73 @@
+package httpparse_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestConnectionInfo tests that connection information is reported
+// for traced and untraced requests.
+func TestConnectionInfo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+	get := func(ctx context.Context) httpparse.ConnInfo {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := srv.Client().Do(req.WithContext(ctx))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := httpparse.RawBody(resp, []int{200}); err != nil {
+			t.Fatal(err)
+		}
+		return httpparse.ConnectionInfo(resp)
+	}
+
+	untraced := get(context.Background())
+	if untraced.Traced || untraced.LocalAddr != nil || untraced.RemoteAddr != nil {
+		t.Errorf("got connection details for an untraced request: %+v", untraced)
+	}
+	if got, want := untraced.Proto, "HTTP/1.1"; got != want {
+		t.Errorf("got protocol %s, wanted %s", got, want)
+	}
+	if untraced.TLS {
+		t.Errorf("got TLS for a plain text connection")
+	}
+
+	// The untraced request left an idle connection in the pool.
+	traced := get(httpparse.TraceConnection(context.Background()))
+	if !traced.Traced || !traced.Reused || !traced.WasIdle {
+		t.Errorf("wanted a traced and reused connection, got %+v", traced)
+	}
+	if got, want := traced.RemoteAddr.String(), srv.Listener.Addr().String(); got != want {
+		t.Errorf("got remote address %s, wanted %s", got, want)
+	}
+	if traced.LocalAddr == nil {
+		t.Errorf("got no local address")
+	}
+}
+
+// TestConnectionInfoTLS tests that the TLS details of a response are
+// reported.
+func TestConnectionInfoTLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	info := httpparse.ConnectionInfo(resp)
+	if !info.TLS || info.TLSVersion == 0 {
+		t.Errorf("wanted TLS details, got %+v", info)
+	}
+}
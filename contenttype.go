@@ -0,0 +1,57 @@
+package httpparse
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// NegotiatedType returns the media type and parameters of the
+// response's Content-Type header. It is handy when the request was sent
+// with a wildcard Accept header and you need to know which format the
+// server actually picked. The media type is lower cased.
+func NegotiatedType(resp *http.Response) (mediaType string, params map[string]string, err error) {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return "", nil, errors.New("response has no Content-Type header")
+	}
+	mediaType, params, err = mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil, fmt.Errorf("parsing Content-Type %q: %v", contentType, err)
+	}
+	return mediaType, params, nil
+}
+
+// checkContentType returns an error unless the response's Content-Type
+// has the given media type, see mediaTypeMatches.
+func checkContentType(resp *http.Response, want string) error {
+	contentType := resp.Header.Get("Content-Type")
+	got, _, err := mime.ParseMediaType(contentType)
+	if err == nil && mediaTypeMatches(got, want) {
+		return nil
+	}
+	if contentType == "" {
+		contentType = "no Content-Type"
+	}
+	return fmt.Errorf("expected Content-Type %s but got %s", want, contentType)
+}
+
+// mediaTypeMatches reports whether the media type got is the wanted
+// one. Media types with a structured syntax suffix (RFC 6839) are in
+// the format of the suffix so application/problem+json is as good as
+// the application/json which was wanted, and a wanted type of just
+// "+json" matches any type with that suffix.
+func mediaTypeMatches(got, want string) bool {
+	got, want = strings.ToLower(got), strings.ToLower(want)
+	if got == want {
+		return true
+	}
+	i := strings.LastIndex(got, "+")
+	if i < 0 {
+		return false
+	}
+	suffix := got[i:]
+	return want == suffix || want == "application/"+suffix[1:]
+}
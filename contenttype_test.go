@@ -0,0 +1,150 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestNegotiatedType tests that the Content-Type header is parsed
+// into a media type and parameters.
+func TestNegotiatedType(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        http.Header
+		wantMediaType string
+		wantParams    map[string]string
+		wantErr       string
+	}{
+		{
+			name:    "no Content-Type",
+			header:  nil,
+			wantErr: "response has no Content-Type header",
+		},
+		{
+			name:    "malformed Content-Type",
+			header:  http.Header{"Content-Type": {"application/json; charset"}},
+			wantErr: `parsing Content-Type "application/json; charset": mime: invalid media parameter`,
+		},
+		{
+			name:          "media type only",
+			header:        http.Header{"Content-Type": {"Application/XML"}},
+			wantMediaType: "application/xml",
+			wantParams:    map[string]string{},
+		},
+		{
+			name:          "media type with parameters",
+			header:        http.Header{"Content-Type": {`text/csv; charset=UTF-8; header="present"`}},
+			wantMediaType: "text/csv",
+			wantParams:    map[string]string{"charset": "UTF-8", "header": "present"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mediaType, params, err := httpparse.NegotiatedType(&http.Response{Header: test.header})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := mediaType, test.wantMediaType; got != want {
+				t.Errorf("got media type %s, wanted %s", got, want)
+			}
+			if got, want := params, test.wantParams; !reflect.DeepEqual(got, want) {
+				t.Errorf("got params %v, wanted %v", got, want)
+			}
+		})
+	}
+}
+
+// TestWithContentType tests that the Content-Type is only checked
+// when asked to.
+func TestWithContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        []httpparse.Option
+		wantData    structuredJSON
+		wantErr     string
+	}{
+		{
+			name:        "not checked by default",
+			contentType: "text/plain",
+			body:        `{"value_two":42}`,
+			opts:        nil,
+			wantData:    structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "matching Content-Type with parameters",
+			contentType: "Application/JSON; charset=utf-8",
+			body:        `{"value_two":42}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantData:    structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "structured syntax suffix",
+			contentType: "application/vnd.api+JSON",
+			body:        `{"value_two":42}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantData:    structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "just the suffix",
+			contentType: "application/problem+json",
+			body:        `{"value_two":42}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("+json")},
+			wantData:    structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "different suffix",
+			contentType: "application/atom+xml",
+			body:        `{"value_two":42}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantData:    structuredJSON{},
+			wantErr:     "expected Content-Type application/json but got application/atom+xml",
+		},
+		{
+			name:        "HTML error page",
+			contentType: "text/html",
+			body:        "<html>oops</html>",
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantData:    structuredJSON{},
+			wantErr:     "expected Content-Type application/json but got text/html; body: <html>oops</html>",
+		},
+		{
+			name:        "missing Content-Type",
+			contentType: "",
+			body:        `{"value_two":42}`,
+			opts:        []httpparse.Option{httpparse.WithContentType("application/json")},
+			wantData:    structuredJSON{},
+			wantErr:     `expected Content-Type application/json but got no Content-Type; body: {"value_two":42}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package httpparse
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// RawBodyContext is RawBody but reading the body is aborted as soon as
+// ctx is done, in which case the error is "reading response body:
+// context canceled" (or deadline exceeded). Without this a slow or
+// huge body keeps being read long after nobody cares about it anymore.
+func RawBodyContext(ctx context.Context, resp *http.Response, wantStatuses []int, opts ...Option) ([]byte, error) {
+	defer abortOnDone(ctx, resp)()
+	opts = append([]Option{func(c *config) { c.ctx = ctx }}, opts...)
+	return rawBody(resp, func(code int) bool { return contains(wantStatuses, code) }, wantStatuses, opts)
+}
+
+// JSONContext is JSON but reading the body is aborted as soon as ctx is
+// done, just like with RawBodyContext.
+func JSONContext(ctx context.Context, resp *http.Response, wantStatuses []int, v interface{}, opts ...Option) error {
+	defer abortOnDone(ctx, resp)()
+	opts = append([]Option{func(c *config) {
+		c.ctx = ctx
+		c.transcode = true
+	}}, opts...)
+	return parseBody(resp, wantStatuses, opts, func(r io.Reader, c *config) error {
+		return decodeJSON(r, v, c)
+	})
+}
+
+// abortOnDone closes the response body once ctx is done, which is the
+// only way to unblock a read that is waiting on a slow server. Call the
+// returned function when done with the body.
+func abortOnDone(ctx context.Context, resp *http.Response) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			resp.Body.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// ctxReader stops reading from r once ctx is done and reports why.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c ctxReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(b)
+	// The body may have been closed out from under us by
+	// abortOnDone.
+	if err != nil && err != io.EOF && c.ctx.Err() != nil {
+		err = c.ctx.Err()
+	}
+	return n, err
+}
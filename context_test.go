@@ -0,0 +1,86 @@
+package httpparse_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestContext tests that reading the body is aborted once the context
+// is done.
+func TestContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		parse   func(ctx context.Context, resp *http.Response) error
+		wantErr string
+	}{
+		{
+			name: "RawBodyContext",
+			parse: func(ctx context.Context, resp *http.Response) error {
+				_, err := httpparse.RawBodyContext(ctx, resp, []int{200})
+				return err
+			},
+			wantErr: "reading response body: context deadline exceeded",
+		},
+		{
+			name: "JSONContext",
+			parse: func(ctx context.Context, resp *http.Response) error {
+				var data structuredJSON
+				return httpparse.JSONContext(ctx, resp, []int{200}, &data)
+			},
+			wantErr: "reading response body: context deadline exceeded",
+		},
+		{
+			name: "JSONContext with a status mismatch",
+			parse: func(ctx context.Context, resp *http.Response) error {
+				var data structuredJSON
+				return httpparse.JSONContext(ctx, resp, []int{201}, &data)
+			},
+			wantErr: "got status code 200 but wanted 201, also an error occurred when reading the response body: context deadline exceeded",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The server sends the beginning of the body and then
+			// hangs.
+			pr, pw := io.Pipe()
+			go pw.Write([]byte(`{"value_one":`))
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			err := test.parse(ctx, &http.Response{StatusCode: 200, Body: pr})
+
+			if got, want := fmt.Sprintf("%v", err), test.wantErr; !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("took %v to abort reading", elapsed)
+			}
+		})
+	}
+}
+
+// TestContextNotDone tests that a context which is not done changes
+// nothing.
+func TestContextNotDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+	}
+	var data structuredJSON
+	if err := httpparse.JSONContext(ctx, resp, []int{200}, &data); err != nil {
+		t.Fatalf("got a non-nil error: %v", err)
+	}
+	if got, want := data, (structuredJSON{ValueOne: "hi"}); got != want {
+		t.Errorf("got data %+v, wanted %+v", got, want)
+	}
+}
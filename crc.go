@@ -0,0 +1,33 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+)
+
+// JSONWithTrailingCRC parses a http response who's body is JSON
+// followed by a 4 byte CRC32 (IEEE polynomial, big endian) of that
+// JSON. The body is read just like RawBody would, the checksum is
+// verified and only then is the JSON unmarshalled into v, the way JSON
+// would do it.
+func JSONWithTrailingCRC(resp *http.Response, wantStatus int, v interface{}, opts ...Option) error {
+	body, err := RawBody(resp, []int{wantStatus}, opts...)
+	if err != nil {
+		return err
+	}
+	if len(body) < crc32.Size {
+		return fmt.Errorf("response body is %d bytes which is too short to end with a CRC32", len(body))
+	}
+	payload, trailer := body[:len(body)-crc32.Size], body[len(body)-crc32.Size:]
+	if got, want := crc32.ChecksumIEEE(payload), binary.BigEndian.Uint32(trailer); got != want {
+		return fmt.Errorf("body CRC mismatch: computed %08x but the body ends with %08x", got, want)
+	}
+	payload, err = transcodeBytes(payload, resp)
+	if err != nil {
+		return err
+	}
+	return DecodeJSONReader(bytes.NewReader(payload), v, opts...)
+}
@@ -0,0 +1,103 @@
+package httpparse_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// withCRC appends the big endian CRC32 of s to s.
+func withCRC(s string) string {
+	var trailer [4]byte
+	binary.BigEndian.PutUint32(trailer[:], crc32.ChecksumIEEE([]byte(s)))
+	return s + string(trailer[:])
+}
+
+// TestJSONWithTrailingCRC tests that the trailing checksum is verified
+// before the JSON is unmarshalled.
+func TestJSONWithTrailingCRC(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "body too short",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("abc")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "response body is 3 bytes which is too short to end with a CRC32",
+		},
+		{
+			name: "checksum mismatch",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}` + "\x00\x00\x00\x00")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "body CRC mismatch: computed",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(withCRC("lats"))),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "unmarshalling response body: invalid character 'l' looking for beginning of value; first 4 bytes were: lats",
+		},
+		{
+			name: "options are applied",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(withCRC(`{"value_two":42,"other":1}`))),
+			},
+			opts:     []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData: structuredJSON{ValueTwo: 42},
+			wantErr:  `unknown field "other"`,
+		},
+		{
+			name: "got the structured data",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(withCRC(`{"value_one":"hello there","value_two":42}`))),
+			},
+			wantData: structuredJSON{ValueOne: "hello there", ValueTwo: 42},
+			wantErr:  "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.JSONWithTrailingCRC(test.resp, 200, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
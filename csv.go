@@ -0,0 +1,124 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// CSV parses a http response who's body contains CSV into its records.
+// The body is read just like RawBody reads it and then parsed with
+// encoding/csv. A UTF-8 byte order mark, which spreadsheet programs
+// like to put at the start, is dropped just like JSON drops it. See
+// WithCSVDelimiter, WithCSVLenient and WithCSVLazyQuotes for the
+// options which change how it is parsed.
+func CSV(resp *http.Response, wantStatus int, opts ...Option) ([][]string, error) {
+	r, err := csvReader(resp, wantStatus, opts)
+	if err != nil {
+		return nil, err
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, &DecodeError{Err: err, doing: "parsing CSV response body"}
+	}
+	return records, nil
+}
+
+// csvReader reads the body of resp and returns a reader for the CSV in
+// it, set up according to the options.
+func csvReader(resp *http.Response, wantStatus int, opts []Option) (*csv.Reader, error) {
+	body, err := RawBody(resp, []int{wantStatus}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := newConfig(opts)
+	if bytes.HasPrefix(body, utf8BOM) {
+		body = body[len(utf8BOM):]
+		c.foundBOM("UTF-8")
+	}
+	r := csv.NewReader(bytes.NewReader(body))
+	if c.csvComma != 0 {
+		r.Comma = c.csvComma
+	}
+	if c.csvLenient {
+		r.FieldsPerRecord = -1
+	}
+	r.LazyQuotes = c.csvLazyQuotes
+	return r, nil
+}
+
+// CSVInto parses a http response who's body contains CSV with a header
+// row into the slice of structs v points to, one struct per record. A
+// column is stored in the field with a matching csv tag, e.g.
+// `csv:"created_at"`, or in an untagged field named after the column.
+// Fields tagged `csv:"-"` are skipped, as are columns without a field.
+// Fields may be strings, bools, integers or floats. It is parsed just
+// like CSV parses it.
+func CSVInto(resp *http.Response, wantStatus int, v interface{}, opts ...Option) error {
+	r, err := csvReader(resp, wantStatus, opts)
+	if err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice || rv.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("parsing CSV response body: need a non-nil pointer to a slice of structs, got %T", v)
+	}
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return &DecodeError{Err: err, doing: "parsing CSV response body"}
+	}
+	typ := rv.Elem().Type().Elem()
+	// columns[i] is the index of the field column i goes in, -1 if
+	// there is none.
+	columns := make([]int, len(header))
+	for i, name := range header {
+		columns[i] = -1
+		for j := 0; j < typ.NumField(); j++ {
+			field := typ.Field(j)
+			if field.PkgPath != "" {
+				continue
+			}
+			fieldName := field.Name
+			if tag := field.Tag.Get("csv"); tag == "-" {
+				continue
+			} else if tag != "" {
+				fieldName = tag
+			}
+			if fieldName == name {
+				columns[i] = j
+				break
+			}
+		}
+	}
+	rows := reflect.MakeSlice(rv.Elem().Type(), 0, 0)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return &DecodeError{Err: err, doing: "parsing CSV response body"}
+		}
+		row := reflect.New(typ).Elem()
+		for col, val := range record {
+			if col >= len(columns) || columns[col] < 0 {
+				continue
+			}
+			if err := setValue(row.Field(columns[col]), val); err != nil {
+				// A quoted field may span lines so only the reader
+				// knows which line it is on.
+				line, _ := r.FieldPos(col)
+				return &DecodeError{Err: fmt.Errorf("line %d, column %s: %v", line, header[col], err), doing: "parsing CSV response body"}
+			}
+		}
+		rows = reflect.Append(rows, row)
+	}
+	rv.Elem().Set(rows)
+	return nil
+}
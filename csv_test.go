@@ -0,0 +1,189 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestCSV tests that CSV response bodies are parsed into records.
+func TestCSV(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		opts        []httpparse.Option
+		wantRecords [][]string
+		wantErr     string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "records",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob,1\n\"smith, jane\",2\n")),
+			},
+			wantRecords: [][]string{{"name", "count"}, {"bob", "1"}, {"smith, jane", "2"}},
+		},
+		{
+			name: "semicolon delimiter",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name;count\nbob;1,5\n")),
+			},
+			opts:        []httpparse.Option{httpparse.WithCSVDelimiter(';')},
+			wantRecords: [][]string{{"name", "count"}, {"bob", "1,5"}},
+		},
+		{
+			name: "wrong number of fields",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob\n")),
+			},
+			wantErr: "parsing CSV response body: record on line 2: wrong number of fields",
+		},
+		{
+			name: "wrong number of fields when lenient",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob\n")),
+			},
+			opts:        []httpparse.Option{httpparse.WithCSVLenient()},
+			wantRecords: [][]string{{"name", "count"}, {"bob"}},
+		},
+		{
+			name: "malformed quotes",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\n\"bob,1\n")),
+			},
+			wantErr: "parsing CSV response body: parse error on line 2",
+		},
+		{
+			name: "stray quotes with lazy quotes",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob \"the builder\",1\n")),
+			},
+			opts:        []httpparse.Option{httpparse.WithCSVLazyQuotes()},
+			wantRecords: [][]string{{"name", "count"}, {"bob \"the builder\"", "1"}},
+		},
+		{
+			name: "response body too large",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob,1\n")),
+			},
+			opts:    []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr: "The response body contained more than the limit of 5 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			records, err := httpparse.CSV(test.resp, 200, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := records, test.wantRecords; !reflect.DeepEqual(got, want) {
+				t.Errorf("got records %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+type csvRow struct {
+	Name    string `csv:"name"`
+	Count   int    `csv:"count"`
+	Active  bool
+	Ignored string `csv:"-"`
+}
+
+// TestCSVInto tests that CSV response bodies are parsed into structs.
+func TestCSVInto(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantRows []csvRow
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "value of the wrong type",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\nbob,1\njane,lots\n")),
+			},
+			wantErr: `parsing CSV response body: line 3, column count: strconv.ParseInt: parsing "lots": invalid syntax`,
+		},
+		{
+			name: "value of the wrong type after a quoted newline",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("name,count\n\"bob\nsmith\",1\njane,lots\n")),
+			},
+			wantErr: `parsing CSV response body: line 4, column count: strconv.ParseInt: parsing "lots": invalid syntax`,
+		},
+		{
+			name: "empty body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			},
+			wantRows: nil,
+		},
+		{
+			name: "header with a byte order mark",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("\xef\xbb\xbfname,count\nbob,1\n")),
+			},
+			wantRows: []csvRow{{Name: "bob", Count: 1}},
+		},
+		{
+			name: "rows",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("Ignored;count;other;Active;name\nx;1;y;true;bob\nx;2;y;false;jane\n")),
+			},
+			opts:     []httpparse.Option{httpparse.WithCSVDelimiter(';')},
+			wantRows: []csvRow{{Name: "bob", Count: 1, Active: true}, {Name: "jane", Count: 2}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rows []csvRow
+			err := httpparse.CSVInto(test.resp, 200, &rows, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := rows, test.wantRows; test.wantErr == "" && !reflect.DeepEqual(got, want) {
+				t.Errorf("got rows %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
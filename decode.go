@@ -0,0 +1,20 @@
+package httpparse
+
+import "net/http"
+
+// Decode is JSON for those who would rather get the value back than
+// pass in a pointer to it:
+//
+//	user, err := httpparse.Decode[User](resp, []int{200})
+//
+// It behaves exactly like JSON, including the options it takes and the
+// errors it returns. On error the zero T is returned, never a
+// partially decoded one.
+func Decode[T any](resp *http.Response, wantStatuses []int, opts ...Option) (T, error) {
+	var v T
+	if err := JSON(resp, wantStatuses, &v, opts...); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
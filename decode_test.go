@@ -0,0 +1,73 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestDecode tests that the decoded value is returned.
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "got status code 999 but wanted one of [200 201], body: woa there",
+		},
+		{
+			name: "partially decoded value is not returned",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi","value_two":"nope"}`)),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "unmarshalling response body: json: cannot unmarshal string",
+		},
+		{
+			name: "response body too large",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there"}`)),
+			},
+			opts:     []httpparse.Option{httpparse.WithReadLimit(10)},
+			wantData: structuredJSON{},
+			wantErr:  "The response body contained more than the limit of 10 bytes",
+		},
+		{
+			name: "successfully decoded",
+			resp: &http.Response{
+				StatusCode: 201,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi","value_two":42}`)),
+			},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := httpparse.Decode[structuredJSON](test.resp, []int{200, 201}, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
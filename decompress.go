@@ -0,0 +1,147 @@
+package httpparse
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// gzipMagic are the first two bytes of every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// DecompressFunc returns a reader which decompresses what it reads
+// from r, like gzip.NewReader does.
+type DecompressFunc func(r io.Reader) (io.Reader, error)
+
+var (
+	decompressorsMu sync.RWMutex
+	decompressors   = map[string]DecompressFunc{}
+)
+
+// RegisterDecompressor teaches httpparse to undo the given content
+// coding, e.g. "br", with fn so that bodies with it in their
+// Content-Encoding header get decompressed like gzip ones do. gzip and
+// deflate are built in. Registering a nil fn removes the decompressor
+// again. It is safe to call from multiple goroutines but is meant to be
+// called once, when the program starts, which is what the packages
+// providing decompressors (like brotliparse) do when imported.
+func RegisterDecompressor(coding string, fn DecompressFunc) {
+	coding = strings.ToLower(coding)
+	decompressorsMu.Lock()
+	defer decompressorsMu.Unlock()
+	if fn == nil {
+		delete(decompressors, coding)
+		return
+	}
+	decompressors[coding] = fn
+}
+
+// registeredDecompressor returns the decompressor registered for
+// coding, nil if there is none.
+func registeredDecompressor(coding string) DecompressFunc {
+	decompressorsMu.RLock()
+	defer decompressorsMu.RUnlock()
+	return decompressors[coding]
+}
+
+// decompress undoes the content codings listed in a Content-Encoding
+// header. Codings are applied in the order they are listed so they get
+// undone in reverse. We stop at the first coding we do not know how to
+// undo, leaving the body as is from there on.
+func decompress(body io.Reader, contentEncoding string) (io.Reader, error) {
+	codings := strings.Split(contentEncoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var err error
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "gzip", "x-gzip":
+			body, err = gunzip(body)
+		case "deflate":
+			body, err = inflate(body)
+		case "", "identity":
+		default:
+			fn := registeredDecompressor(coding)
+			if fn == nil {
+				return body, nil
+			}
+			body, err = decompressWith(body, coding, fn)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// sniffGzip decompresses body if it starts with the gzip magic number.
+func sniffGzip(body io.Reader) (io.Reader, error) {
+	br, ok := body.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(body)
+	}
+	// A body shorter than two bytes cannot be gzipped so a failed
+	// Peek() just means we leave it alone. Any real read error will
+	// resurface when the body is read for real.
+	magic, _ := br.Peek(len(gzipMagic))
+	if string(magic) != string(gzipMagic) {
+		return br, nil
+	}
+	return gunzip(br)
+}
+
+func gunzip(body io.Reader) (io.Reader, error) {
+	zr, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip response body: %v", err)
+	}
+	// A gzip stream may consist of several concatenated members
+	// which together make up the body. This is already the default
+	// but it is important enough to be explicit about.
+	zr.Multistream(true)
+	return decompressErrReader{r: zr, coding: "gzip"}, nil
+}
+
+// decompressWith decompresses a body with the given coding using the
+// registered decompressor fn.
+func decompressWith(body io.Reader, coding string, fn DecompressFunc) (io.Reader, error) {
+	r, err := fn(body)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing %s response body: %v", coding, err)
+	}
+	return decompressErrReader{r: r, coding: coding}, nil
+}
+
+// inflate decompresses a "deflate" coded body. The coding is supposed
+// to be the zlib format but plenty of servers send raw deflate data
+// instead so we look at the first two bytes to figure out which it is.
+func inflate(body io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(body)
+	header, _ := br.Peek(2)
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint(header[0])<<8|uint(header[1]))%31 == 0 {
+		zr, err := zlib.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing deflate response body: %v", err)
+		}
+		return decompressErrReader{r: zr, coding: "deflate"}, nil
+	}
+	return decompressErrReader{r: flate.NewReader(br), coding: "deflate"}, nil
+}
+
+// decompressErrReader makes it clear that errors from reading a
+// decompressing reader are most likely due to the decompression.
+type decompressErrReader struct {
+	r      io.Reader
+	coding string
+}
+
+func (d decompressErrReader) Read(b []byte) (int, error) {
+	n, err := d.r.Read(b)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("decompressing %s response body: %v", d.coding, err)
+	}
+	return n, err
+}
@@ -0,0 +1,430 @@
+package httpparse_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// gzipped returns the gzip compressed form of each string
+// concatenated together, one gzip member per string.
+func gzipped(members ...string) string {
+	var buf bytes.Buffer
+	for _, m := range members {
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(m))
+		zw.Close()
+	}
+	return buf.String()
+}
+
+// TestGzipSniffing tests that gzipped response bodies without a
+// Content-Encoding header are decompressed only when asked to.
+func TestGzipSniffing(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name: "gzipped body is not decompressed by default",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(gzipped(`{"value_one":"hi"}`))),
+			},
+			opts:     nil,
+			wantData: structuredJSON{},
+			wantErr:  "unmarshalling response body: invalid character",
+		},
+		{
+			name: "gzipped body is decompressed when sniffing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(gzipped(`{"value_one":"hi"}`))),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{ValueOne: "hi"},
+			wantErr:  "",
+		},
+		{
+			name: "every member of a multi-member gzip body is decompressed",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(gzipped(`{"value_one":`, `"hi",`, `"value_two":42}`))),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 42},
+			wantErr:  "",
+		},
+		{
+			name: "plain body is left alone when sniffing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}`)),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{ValueTwo: 42},
+			wantErr:  "",
+		},
+		{
+			name: "body shorter than the magic number when sniffing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`1`)),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{},
+			wantErr:  "unmarshalling response body: json: cannot unmarshal number",
+		},
+		{
+			name: "body with the magic number but a corrupt gzip header",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("\x1f\x8bnot really gzip")),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{},
+			wantErr:  "decompressing gzip response body: gzip: invalid header",
+		},
+		{
+			name: "gzipped body is decompressed for the status mismatch error",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader(gzipped("oh no"))),
+			},
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{},
+			wantErr:  "got status code 500 but wanted 200, body: oh no",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.JSON(test.resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+func zlibbed(s string) string {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.String()
+}
+
+func deflated(s string) string {
+	var buf bytes.Buffer
+	zw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.String()
+}
+
+// TestContentEncoding tests that response bodies are decompressed
+// according to their Content-Encoding header.
+func TestContentEncoding(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            string
+		readLimit       int64
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:            "no encoding",
+			contentEncoding: "",
+			body:            "hello there",
+			wantBody:        "hello there",
+		},
+		{
+			name:            "gzip",
+			contentEncoding: "gzip",
+			body:            gzipped("hello there"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "zlib wrapped deflate",
+			contentEncoding: "Deflate",
+			body:            zlibbed("hello there"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "raw deflate",
+			contentEncoding: "deflate",
+			body:            deflated("hello there"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "multiple encodings",
+			contentEncoding: "deflate, gzip",
+			body:            gzipped(zlibbed("hello there")),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "unknown encodings are left alone",
+			contentEncoding: "br",
+			body:            "not really brotli",
+			wantBody:        "not really brotli",
+		},
+		{
+			name:            "corrupt gzip header",
+			contentEncoding: "gzip",
+			body:            "hello there",
+			wantErr:         "decompressing gzip response body: gzip: invalid header",
+		},
+		{
+			name:            "truncated gzip body",
+			contentEncoding: "gzip",
+			body:            gzipped("hello there")[:15],
+			wantErr:         "reading response body: decompressing gzip response body: unexpected EOF",
+		},
+		{
+			name:            "limit applies to the decompressed body",
+			contentEncoding: "gzip",
+			body:            gzipped(strings.Repeat("a", 1000)),
+			readLimit:       100,
+			wantErr:         "The response body decompressed into more than the limit of 100 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var opts []httpparse.Option
+			if test.readLimit != 0 {
+				opts = append(opts, httpparse.WithReadLimit(test.readLimit))
+			}
+			body, err := httpparse.RawBody(resp, []int{200}, opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestContentEncodingJSON tests that JSON decompresses response bodies
+// according to their Content-Encoding header.
+func TestContentEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "gzip",
+			body:     gzipped(`{"value_one":"hello there","value_two":42}`),
+			opts:     nil,
+			wantData: structuredJSON{ValueOne: "hello there", ValueTwo: 42},
+		},
+		{
+			name:     "gzip with sniffing does not decompress twice",
+			body:     gzipped(`{"value_one":"hello there","value_two":42}`),
+			opts:     []httpparse.Option{httpparse.WithGzipSniffing()},
+			wantData: structuredJSON{ValueOne: "hello there", ValueTwo: 42},
+		},
+		{
+			name:     "limit applies to the decompressed body",
+			body:     gzipped(`{"value_one":"` + strings.Repeat("a", 1000) + `"}`),
+			opts:     []httpparse.Option{httpparse.WithReadLimit(100)},
+			wantData: structuredJSON{},
+			wantErr:  "The response body decompressed into more than the limit of 100 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {"gzip"}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestRegisterDecompressor tests that bodies with a registered content
+// coding are decompressed with the registered decompressor.
+func TestRegisterDecompressor(t *testing.T) {
+	httpparse.RegisterDecompressor("X-Reverse", func(r io.Reader) (io.Reader, error) {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) == 0 {
+			return nil, errors.New("nothing to reverse")
+		}
+		for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+			b[i], b[j] = b[j], b[i]
+		}
+		return bytes.NewReader(b), nil
+	})
+	defer httpparse.RegisterDecompressor("x-reverse", nil)
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            string
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:            "registered coding",
+			contentEncoding: "x-reverse",
+			body:            "ereht olleh",
+			wantBody:        "hello there",
+		},
+		{
+			name:            "registered coding after gzip",
+			contentEncoding: "x-reverse, gzip",
+			body:            gzipped("ereht olleh"),
+			wantBody:        "hello there",
+		},
+		{
+			name:            "decompressor fails",
+			contentEncoding: "x-reverse",
+			body:            "",
+			wantErr:         "decompressing x-reverse response body: nothing to reverse",
+		},
+		{
+			name:            "unknown coding is left alone",
+			contentEncoding: "x-unknown",
+			body:            "ereht olleh",
+			wantBody:        "ereht olleh",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			body, err := httpparse.RawBody(resp, []int{200})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestCompressedReadLimit tests that the compressed and decompressed
+// sizes of a response body are limited separately.
+func TestCompressedReadLimit(t *testing.T) {
+	bomb := gzipped(strings.Repeat("a", 100000))
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            string
+		opts            []httpparse.Option
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:            "within both limits",
+			contentEncoding: "gzip",
+			body:            gzipped("hello there"),
+			opts:            []httpparse.Option{httpparse.WithCompressedReadLimit(100), httpparse.WithReadLimit(100)},
+			wantBody:        "hello there",
+		},
+		{
+			name:            "compressed body too large",
+			contentEncoding: "gzip",
+			body:            bomb,
+			opts:            []httpparse.Option{httpparse.WithCompressedReadLimit(50)},
+			wantErr:         "The compressed response body contained more than the limit of 50 bytes",
+		},
+		{
+			name:            "small compressed body decompresses into too much",
+			contentEncoding: "gzip",
+			body:            bomb,
+			opts:            []httpparse.Option{httpparse.WithCompressedReadLimit(1000), httpparse.WithReadLimit(1000)},
+			wantErr:         "The response body decompressed into more than the limit of 1000 bytes, a small body which decompresses into a huge one may be a decompression bomb",
+		},
+		{
+			name:            "compressed limit ignores uncompressed bodies",
+			contentEncoding: "",
+			body:            strings.Repeat("a", 100),
+			opts:            []httpparse.Option{httpparse.WithCompressedReadLimit(50)},
+			wantBody:        strings.Repeat("a", 100),
+		},
+		{
+			name:            "compressed limit applies to sniffed bodies",
+			contentEncoding: "",
+			body:            bomb,
+			opts:            []httpparse.Option{httpparse.WithGzipSniffing(), httpparse.WithCompressedReadLimit(50)},
+			wantErr:         "The compressed response body contained more than the limit of 50 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			body, err := httpparse.RawBody(resp, []int{200}, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if want := test.wantErr; want != "" && !errors.Is(err, httpparse.ErrBodyTooLarge) {
+				t.Errorf("got error %v, wanted it to be ErrBodyTooLarge", err)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
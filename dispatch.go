@@ -0,0 +1,98 @@
+package httpparse
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DecodeByContentType parses a http response into v according to its
+// Content-Type header, for APIs which answer in more than one format:
+//
+//   - JSON (application/json or any "+json" type) is decoded like JSON
+//     decodes it.
+//   - XML (application/xml, text/xml or any "+xml" type) is decoded like
+//     XML decodes it.
+//   - application/x-www-form-urlencoded is decoded into v when it is a
+//     *url.Values or otherwise into a struct like FormInto decodes it.
+//   - Any other text type, like text/plain, is stored in v when it is a
+//     *string or *[]byte with a single trailing newline trimmed off
+//     like Text does.
+//
+// Other media types can be added with RegisterDecoder, everything
+// else, including a missing Content-Type, is an error. Otherwise it
+// works just like JSON, including its limits and error messages.
+func DecodeByContentType(resp *http.Response, wantStatuses []int, v interface{}, opts ...Option) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	decode, transcode := decoderFor(mediaType)
+	opts = append([]Option{func(c *config) { c.transcode = transcode }}, opts...)
+	return parseBody(resp, wantStatuses, opts, func(r io.Reader, c *config) error {
+		if decode == nil {
+			contentType := resp.Header.Get("Content-Type")
+			if contentType == "" {
+				contentType = "no Content-Type"
+			}
+			return fmt.Errorf("unsupported Content-Type %s", contentType)
+		}
+		return decode(r, v, c)
+	})
+}
+
+// decoderFor returns the function decoding the given media type, nil if
+// there is none, and whether the body should be transcoded to UTF-8
+// first.
+func decoderFor(mediaType string) (decode func(r io.Reader, v interface{}, c *config) error, transcode bool) {
+	if decode := registeredDecoder(mediaType); decode != nil {
+		return decode, false
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return decodeJSON, true
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return decodeXML, true
+	case mediaType == "application/x-www-form-urlencoded":
+		return decodeForm, false
+	case strings.HasPrefix(mediaType, "text/"):
+		return decodeText, true
+	}
+	return nil, false
+}
+
+// decodeForm decodes a form-encoded body into v, a *url.Values or a
+// pointer to a struct.
+func decodeForm(r io.Reader, v interface{}, c *config) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	if vals, ok := v.(*url.Values); ok {
+		*vals = values
+		return nil
+	}
+	return formInto(values, v)
+}
+
+// decodeText stores a text body in v, a *string or *[]byte.
+func decodeText(r io.Reader, v interface{}, c *config) error {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case *string:
+		*v = trimNewline(string(body))
+	case *[]byte:
+		*v = []byte(trimNewline(string(body)))
+	default:
+		return fmt.Errorf("need a *string or *[]byte for a text body, got %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestDecodeByContentType tests that response bodies are decoded
+// according to their Content-Type.
+func TestDecodeByContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		v           func() interface{}
+		want        interface{}
+		wantErr     string
+	}{
+		{
+			name:        "JSON",
+			contentType: "application/json; charset=utf-8",
+			body:        `{"value_one":"hi","value_two":42}`,
+			v:           func() interface{} { return &structuredJSON{} },
+			want:        &structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+		{
+			name:        "vendor JSON",
+			contentType: "application/vnd.api+json",
+			body:        `{"value_two":42}`,
+			v:           func() interface{} { return &structuredJSON{} },
+			want:        &structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "XML",
+			contentType: "text/xml",
+			body:        `<data><value_one>hi</value_one></data>`,
+			v:           func() interface{} { return &structuredXML{} },
+			want:        &structuredXML{ValueOne: "hi"},
+		},
+		{
+			name:        "form into url.Values",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "a=1&a=2&b=3",
+			v:           func() interface{} { return &url.Values{} },
+			want:        &url.Values{"a": {"1", "2"}, "b": {"3"}},
+		},
+		{
+			name:        "form into a struct",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "access_token=abc&expires_in=3600",
+			v:           func() interface{} { return &tokenForm{} },
+			want:        &tokenForm{AccessToken: "abc", ExpiresIn: 3600},
+		},
+		{
+			name:        "text",
+			contentType: "text/plain",
+			body:        "hello there\n",
+			v:           func() interface{} { s := ""; return &s },
+			want:        func() *string { s := "hello there"; return &s }(),
+		},
+		{
+			name:        "text into the wrong type",
+			contentType: "text/plain",
+			body:        "hello there",
+			v:           func() interface{} { return &structuredJSON{} },
+			wantErr:     "unmarshalling response body: need a *string or *[]byte for a text body, got *httpparse_test.structuredJSON",
+		},
+		{
+			name:        "unsupported media type",
+			contentType: "image/png",
+			body:        "not really a png",
+			v:           func() interface{} { return &structuredJSON{} },
+			wantErr:     "unsupported Content-Type image/png",
+		},
+		{
+			name:        "no Content-Type",
+			contentType: "",
+			body:        `{"value_two":42}`,
+			v:           func() interface{} { return &structuredJSON{} },
+			wantErr:     "unsupported Content-Type no Content-Type",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			v := test.v()
+			err := httpparse.DecodeByContentType(resp, []int{200}, v)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := v, test.want; test.wantErr == "" && !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestDecodeByContentTypeStatus tests that the status code is checked
+// before the Content-Type.
+func TestDecodeByContentTypeStatus(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 502,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       ioutil.NopCloser(strings.NewReader("<html>Bad Gateway</html>")),
+	}
+	var data structuredJSON
+	err := httpparse.DecodeByContentType(resp, []int{200}, &data)
+
+	if got, want := fmt.Sprintf("%v", err), "got status code 502 but wanted 200, body: <html>Bad Gateway</html>"; got != want {
+		t.Errorf("got error %s, wanted %s", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package httpparse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// DoJSON performs the request with client (http.DefaultClient if it is
+// nil) and parses the response just like JSON does, closing the body
+// included, so the usual build-request/Do/parse dance becomes one call.
+// An error performing the request is returned as "performing request:
+// ...".
+func DoJSON(client *http.Client, req *http.Request, wantStatus int, v interface{}, opts ...Option) error {
+	return Do(req.Context(), client, req, []int{wantStatus}, v, opts...)
+}
+
+// Do is DoJSON for those who want a context and more than one wanted
+// status code. The request is performed with ctx, hedged if WithHedge
+// says so, and the response parsed just like JSONContext does.
+func Do(ctx context.Context, client *http.Client, req *http.Request, wantStatuses []int, v interface{}, opts ...Option) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := newConfig(opts)
+	if err := c.allow(); err != nil {
+		return err
+	}
+	resp, err := c.send(ctx, client, req)
+	if err != nil {
+		return fmt.Errorf("performing request: %v", err)
+	}
+	parse := func(resp *http.Response) error {
+		return JSONContext(ctx, resp, wantStatuses, v, opts...)
+	}
+	return c.retryDecodeError(ctx, client, req, parse(resp), parse)
+}
@@ -0,0 +1,264 @@
+package httpparse_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestDoJSON tests that the request is performed and its response
+// parsed.
+func TestDoJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			fmt.Fprint(w, `{"value_one":"hi","value_two":42}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "nope")
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		client   *http.Client
+		url      string
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "default client",
+			client:   nil,
+			url:      srv.URL + "/ok",
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+		{
+			name:     "given client",
+			client:   srv.Client(),
+			url:      srv.URL + "/ok",
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+		{
+			name:    "unexpected response status code",
+			client:  nil,
+			url:     srv.URL + "/missing",
+			wantErr: "got status code 404 but wanted 200, body: nope",
+		},
+		{
+			name:    "request fails",
+			client:  nil,
+			url:     "http://127.0.0.1:0/ok",
+			wantErr: "performing request: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data structuredJSON
+			err = httpparse.DoJSON(test.client, req, 200, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestDo tests that the request is performed with the context and its
+// response parsed.
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"value_one":"hi","value_two":42}`)
+		case "/slow":
+			<-r.Context().Done()
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "nope")
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		url      string
+		timeout  time.Duration
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "one of the wanted status codes",
+			url:      srv.URL + "/created",
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+		{
+			name:    "unexpected response status code",
+			url:     srv.URL + "/missing",
+			wantErr: "got status code 404 but wanted one of [200 201], body: nope",
+		},
+		{
+			name:    "context done",
+			url:     srv.URL + "/slow",
+			timeout: 50 * time.Millisecond,
+			wantErr: "performing request: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+			req, err := http.NewRequest("GET", test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data structuredJSON
+			err = httpparse.Do(ctx, nil, req, []int{200, 201}, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestDoRetryOnDecodeError tests that a request is performed once more
+// when its response cannot be decoded, if it is idempotent and can be
+// replayed.
+func TestDoRetryOnDecodeError(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		body         func() io.Reader
+		bodies       []string
+		opts         []httpparse.Option
+		wantAttempts int
+		wantData     structuredJSON
+		wantErr      string
+	}{
+		{
+			name:         "not retried without the option",
+			bodies:       []string{`{"value_one":`, `{"value_one":"hi"}`},
+			wantAttempts: 1,
+			wantErr:      "unmarshalling response body: unexpected EOF",
+		},
+		{
+			name:         "retried once",
+			bodies:       []string{`{"value_one":`, `{"value_one":"hi"}`},
+			opts:         []httpparse.Option{httpparse.WithRetryOnDecodeError()},
+			wantAttempts: 2,
+			wantData:     structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:         "gives up after the retry",
+			bodies:       []string{`{"value_one":`, `{"value_one":1}`, `{"value_one":"hi"}`},
+			opts:         []httpparse.Option{httpparse.WithRetryOnDecodeError()},
+			wantAttempts: 2,
+			wantErr:      "unmarshalling response body: json: cannot unmarshal number",
+		},
+		{
+			name:         "request body replayed",
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			bodies:       []string{`{"value_one":`, `{"value_one":"hi"}`},
+			opts:         []httpparse.Option{httpparse.WithRetryOnDecodeError()},
+			wantAttempts: 2,
+			wantData:     structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:         "non-idempotent request",
+			method:       "POST",
+			body:         func() io.Reader { return strings.NewReader("{}") },
+			bodies:       []string{`{"value_one":`, `{"value_one":"hi"}`},
+			opts:         []httpparse.Option{httpparse.WithRetryOnDecodeError()},
+			wantAttempts: 1,
+			wantErr:      "unmarshalling response body: unexpected EOF",
+		},
+		{
+			name:         "request body cannot be replayed",
+			body:         func() io.Reader { return io.MultiReader(strings.NewReader("{}")) },
+			bodies:       []string{`{"value_one":`, `{"value_one":"hi"}`},
+			opts:         []httpparse.Option{httpparse.WithRetryOnDecodeError()},
+			wantAttempts: 1,
+			wantErr:      "unmarshalling response body: unexpected EOF",
+		},
+	}
+	for _, test := range tests {
+		for _, do := range []struct {
+			name string
+			fn   func(ctx context.Context, client *http.Client, req *http.Request, wantStatuses []int, v interface{}, opts ...httpparse.Option) error
+		}{
+			{"Do", httpparse.Do},
+			{"DoWithRetry", httpparse.DoWithRetry},
+		} {
+			t.Run(do.name+"/"+test.name, func(t *testing.T) {
+				attempts := 0
+				client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					attempts++
+					if req.Body != nil {
+						if body, _ := ioutil.ReadAll(req.Body); string(body) != "{}" {
+							t.Errorf("attempt %d got request body %q, wanted %q", attempts, body, "{}")
+						}
+					}
+					return &http.Response{
+						StatusCode: 200,
+						Body:       ioutil.NopCloser(strings.NewReader(test.bodies[attempts-1])),
+						Request:    req,
+					}, nil
+				})}
+				var body io.Reader
+				if test.body != nil {
+					body = test.body()
+				}
+				method := test.method
+				if method == "" {
+					method = "PUT"
+				}
+				req, err := http.NewRequest(method, "http://example.com/things", body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var data structuredJSON
+				err = do.fn(context.Background(), client, req, []int{200}, &data, test.opts...)
+
+				if test.wantErr == "" && err != nil {
+					t.Errorf("got a non-nil error: %v", err)
+				} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+					t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+				}
+				if got, want := attempts, test.wantAttempts; got != want {
+					t.Errorf("got %d attempts, wanted %d", got, want)
+				}
+				if got, want := data, test.wantData; got != want {
+					t.Errorf("got data %+v, wanted %+v", got, want)
+				}
+			})
+		}
+	}
+}
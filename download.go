@@ -0,0 +1,105 @@
+package httpparse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Progress is how far along Download is.
+type Progress struct {
+	// Bytes is the number of bytes written so far.
+	Bytes int64
+	// Total is the number of bytes the body is supposed to have
+	// according to its Content-Length, -1 when that is unknown
+	// (including when the body gets decompressed, since the
+	// Content-Length is that of the compressed body).
+	Total int64
+}
+
+// Percent returns how much of the body has been written, as a
+// percentage of Total, or -1 if Total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Bytes) * 100 / float64(p.Total)
+}
+
+// Download copies the body of a http response into w, which could be a
+// file, a hash or a pipe, without holding it in memory and closes the
+// response body. It returns the number of bytes written. The status
+// code is checked, the body decompressed and the read limit enforced
+// just like RawBody does, except that the bytes up to the limit are
+// already written by the time it errors. See WithProgress for keeping
+// track of long downloads.
+func Download(resp *http.Response, wantStatuses []int, w io.Writer, opts ...Option) (int64, error) {
+	return DownloadContext(context.Background(), resp, wantStatuses, w, opts...)
+}
+
+// DownloadContext is Download but it is aborted as soon as ctx is done,
+// just like with RawBodyContext.
+func DownloadContext(ctx context.Context, resp *http.Response, wantStatuses []int, w io.Writer, opts ...Option) (int64, error) {
+	defer abortOnDone(ctx, resp)()
+	c := newConfig(append([]Option{func(c *config) { c.ctx = ctx }}, opts...))
+	defer drainAndClose(resp.Body, c.readLimit)
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		return 0, err
+	}
+	if got, wants := resp.StatusCode, wantStatuses; !contains(wants, got) && !c.acceptsStatus(got) {
+		return 0, mismatchError(resp, r, c, wants)
+	}
+	pw := &progressWriter{w: w, fn: c.progress, every: c.progressEvery}
+	pw.p.Total = resp.ContentLength
+	if c.compressed != nil || pw.p.Total < 0 {
+		pw.p.Total = -1
+	}
+	body := &limitedBody{lr: &io.LimitedReader{R: r, N: c.readLimit + 1}, c: c}
+	n, err := io.Copy(pw, body)
+	if pw.err != nil {
+		return n, fmt.Errorf("writing response body: %v", pw.err)
+	}
+	if _, ok := err.(tooLargeError); ok {
+		return n, err
+	}
+	if err != nil {
+		return n, &ReadError{Err: err}
+	}
+	pw.report()
+	return n, nil
+}
+
+// progressWriter writes to w, telling fn about its progress no more
+// than once every so often.
+type progressWriter struct {
+	w     io.Writer
+	fn    func(Progress)
+	every time.Duration
+	last  time.Time
+	p     Progress
+	err   error
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.p.Bytes += int64(n)
+	if err != nil {
+		pw.err = err
+		return n, err
+	}
+	if time.Since(pw.last) >= pw.every {
+		pw.report()
+	}
+	return n, nil
+}
+
+// report tells fn about the progress made so far.
+func (pw *progressWriter) report() {
+	if pw.fn != nil {
+		pw.fn(pw.p)
+		pw.last = time.Now()
+	}
+}
@@ -0,0 +1,141 @@
+package httpparse_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// failWriter fails every write.
+type failWriter struct{}
+
+func (failWriter) Write(b []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestDownload tests that the body is written to the writer, up to the
+// limit, once the status code checks out.
+func TestDownload(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusCode      int
+		contentEncoding string
+		contentLength   int64
+		body            string
+		opts            []httpparse.Option
+		wantN           int64
+		wantBody        string
+		wantErr         string
+		wantProgress    []httpparse.Progress
+	}{
+		{
+			name:       "unexpected status code",
+			statusCode: 500,
+			body:       "woa there",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:          "body",
+			statusCode:    200,
+			contentLength: 11,
+			body:          "hello there",
+			wantN:         11,
+			wantBody:      "hello there",
+			wantProgress:  []httpparse.Progress{{Bytes: 11, Total: 11}, {Bytes: 11, Total: 11}},
+		},
+		{
+			name:          "over the limit",
+			statusCode:    200,
+			contentLength: 11,
+			body:          "hello there",
+			opts:          []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantN:         5,
+			wantBody:      "hello",
+			wantErr:       "The response body contained more than the limit of 5 bytes",
+			wantProgress:  []httpparse.Progress{{Bytes: 5, Total: 11}},
+		},
+		{
+			name:            "decompressed body has no known total",
+			statusCode:      200,
+			contentEncoding: "gzip",
+			contentLength:   int64(len(gzipped("hello there"))),
+			body:            gzipped("hello there"),
+			wantN:           11,
+			wantBody:        "hello there",
+			wantProgress:    []httpparse.Progress{{Bytes: 11, Total: -1}, {Bytes: 11, Total: -1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode:    test.statusCode,
+				Header:        http.Header{"Content-Encoding": {test.contentEncoding}},
+				ContentLength: test.contentLength,
+				Body:          ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var progress []httpparse.Progress
+			opts := append(test.opts, httpparse.WithProgress(0, func(p httpparse.Progress) {
+				progress = append(progress, p)
+			}))
+			var buf bytes.Buffer
+			n, err := httpparse.Download(resp, []int{200}, &buf, opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := n, test.wantN; got != want {
+				t.Errorf("got %d bytes written, wanted %d", got, want)
+			}
+			if got, want := buf.String(), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+			if got, want := progress, test.wantProgress; !reflect.DeepEqual(got, want) {
+				t.Errorf("got progress %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestDownloadErrors tests that failing to write and the context being
+// done are reported.
+func TestDownloadErrors(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+	}
+	_, err := httpparse.Download(resp, []int{200}, failWriter{})
+	if got, want := fmt.Sprintf("%v", err), "writing response body: disk full"; got != want {
+		t.Errorf("got error message: %s, wanted: %s", got, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp = &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+	}
+	_, err = httpparse.DownloadContext(ctx, resp, []int{200}, ioutil.Discard)
+	if got, want := fmt.Sprintf("%v", err), "reading response body: context canceled"; got != want {
+		t.Errorf("got error message: %s, wanted: %s", got, want)
+	}
+}
+
+// TestProgressPercent tests the percentage of the body written.
+func TestProgressPercent(t *testing.T) {
+	if got, want := (httpparse.Progress{Bytes: 25, Total: 200}).Percent(), 12.5; got != want {
+		t.Errorf("got %v percent, wanted %v", got, want)
+	}
+	if got, want := (httpparse.Progress{Bytes: 25, Total: -1}).Percent(), -1.0; got != want {
+		t.Errorf("got %v percent, wanted %v", got, want)
+	}
+}
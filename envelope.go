@@ -0,0 +1,75 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// EnvelopeError is the error for a JSON body wrapped in an envelope
+// (see WithEnvelope) whose "error" member is not null.
+type EnvelopeError struct {
+	// Code is the "code" member of the error, if it is an object
+	// with one.
+	Code string
+	// Message is the error if it is a string or its "message"
+	// member if it is an object with one.
+	Message string
+	// Raw is the error as it appeared in the body.
+	Raw json.RawMessage
+}
+
+func (e *EnvelopeError) Error() string {
+	switch {
+	case e.Code != "" && e.Message != "":
+		return fmt.Sprintf("response contained error %s: %s", e.Code, e.Message)
+	case e.Message != "":
+		return fmt.Sprintf("response contained error: %s", e.Message)
+	}
+	return fmt.Sprintf("response contained error: %s", e.Raw)
+}
+
+// newEnvelopeError returns the error for the "error" member raw of an
+// envelope.
+func newEnvelopeError(raw json.RawMessage) *EnvelopeError {
+	e := &EnvelopeError{Raw: raw}
+	if err := json.Unmarshal(raw, &e.Message); err == nil {
+		return e
+	}
+	var obj struct {
+		Code    interface{} `json:"code"`
+		Message string      `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil {
+		e.Message = obj.Message
+		if obj.Code != nil {
+			e.Code = fmt.Sprint(obj.Code)
+		}
+	}
+	return e
+}
+
+// unwrapEnvelope reads the envelope in r and returns its "data"
+// member, nil if it has none. The "error" member, if not null, is
+// returned as an *EnvelopeError and the "meta" member is decoded into
+// wherever WithEnvelope said.
+func (c *config) unwrapEnvelope(r io.Reader) (json.RawMessage, error) {
+	var env struct {
+		Data  json.RawMessage `json:"data"`
+		Error json.RawMessage `json:"error"`
+		Meta  json.RawMessage `json:"meta"`
+	}
+	if err := json.NewDecoder(r).Decode(&env); err != nil {
+		return nil, err
+	}
+	if len(env.Error) > 0 && !bytes.Equal(env.Error, []byte("null")) {
+		return nil, newEnvelopeError(env.Error)
+	}
+	if c.envelopeMeta != nil && len(env.Meta) > 0 {
+		if err := json.Unmarshal(env.Meta, c.envelopeMeta); err != nil {
+			return nil, fmt.Errorf("meta: %v", err)
+		}
+	}
+	return env.Data, nil
+}
@@ -0,0 +1,110 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestWithEnvelope tests that the data of an envelope is decoded, its
+// error returned and its meta exposed.
+func TestWithEnvelope(t *testing.T) {
+	type meta struct {
+		Page int `json:"page"`
+	}
+	tests := []struct {
+		name       string
+		body       string
+		noMeta     bool
+		wantData   structuredJSON
+		wantMeta   meta
+		wantErr    string
+		wantEnvErr *httpparse.EnvelopeError
+	}{
+		{
+			name:     "data and meta",
+			body:     `{"data":{"value_one":"hi"},"error":null,"meta":{"page":2}}`,
+			wantData: structuredJSON{ValueOne: "hi"},
+			wantMeta: meta{Page: 2},
+		},
+		{
+			name:     "meta not wanted",
+			body:     `{"data":{"value_one":"hi"},"meta":{"page":2}}`,
+			noMeta:   true,
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:     "no data",
+			body:     `{"meta":{"page":2}}`,
+			wantMeta: meta{Page: 2},
+		},
+		{
+			name:       "string error",
+			body:       `{"data":null,"error":"not allowed"}`,
+			wantErr:    "response contained error: not allowed",
+			wantEnvErr: &httpparse.EnvelopeError{Message: "not allowed"},
+		},
+		{
+			name:       "object error",
+			body:       `{"error":{"code":403,"message":"not allowed"}}`,
+			wantErr:    "response contained error 403: not allowed",
+			wantEnvErr: &httpparse.EnvelopeError{Code: "403", Message: "not allowed"},
+		},
+		{
+			name:       "unrecognized error",
+			body:       `{"error":[1,2]}`,
+			wantErr:    "response contained error: [1,2]",
+			wantEnvErr: &httpparse.EnvelopeError{},
+		},
+		{
+			name:    "data of the wrong type",
+			body:    `{"data":{"value_two":"hi"}}`,
+			wantErr: "unmarshalling response body: json: cannot unmarshal string",
+		},
+		{
+			name:    "meta of the wrong type",
+			body:    `{"data":{"value_one":"hi"},"meta":{"page":"two"}}`,
+			wantErr: "unmarshalling response body: meta: json: cannot unmarshal string",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			var m meta
+			opt := httpparse.WithEnvelope(&m)
+			if test.noMeta {
+				opt = httpparse.WithEnvelope(nil)
+			}
+			err := httpparse.JSON(resp, []int{200}, &data, opt)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			var envErr *httpparse.EnvelopeError
+			if got, want := errors.As(err, &envErr), test.wantEnvErr != nil; got != want {
+				t.Errorf("got an *EnvelopeError %t, wanted %t", got, want)
+			} else if want && (envErr.Code != test.wantEnvErr.Code || envErr.Message != test.wantEnvErr.Message) {
+				t.Errorf("got envelope error %+v, wanted %+v", envErr, test.wantEnvErr)
+			}
+			if test.wantErr == "" {
+				if got, want := data, test.wantData; got != want {
+					t.Errorf("got data %+v, wanted %+v", got, want)
+				}
+				if got, want := m, test.wantMeta; got != want {
+					t.Errorf("got meta %+v, wanted %+v", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package httpparse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrBodyTooLarge is what errors.Is finds in the error returned
+	// when a response body is bigger than the read limit.
+	ErrBodyTooLarge = errors.New("response body is larger than the read limit")
+	// ErrUnexpectedStatus is what errors.Is finds in a StatusError, so
+	// code like a retry loop can tell it apart from other failures
+	// without errors.As. A domain error returned by the classifier set
+	// with WithErrorClassifier replaces the StatusError and with it
+	// ErrUnexpectedStatus.
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+)
+
+// StatusError is returned when a response's status code is not one of
+// those wanted. Use errors.As to get at it when you want to react to
+// particular status codes, like backing off on a 429.
+type StatusError struct {
+	StatusCode int
+	// WantStatuses are the status codes which were wanted. It is
+	// empty when a predicate decided, like with RawBodyFunc.
+	WantStatuses []int
+	// Body is the response body, or as much of it as could be
+	// read.
+	Body []byte
+	// Truncated reports whether Body is only the beginning of the
+	// response body because the rest of it was too big to include.
+	Truncated bool
+	// ReadErr is the error, if any, which occurred while reading
+	// the response body.
+	ReadErr error
+	// Header holds the response headers asked for with
+	// WithErrorHeaders, the ones missing from the response are
+	// left out.
+	Header http.Header
+	// RetryAfter is how long the Retry-After header of a 429 Too Many
+	// Requests or 503 Service Unavailable says to wait before
+	// retrying, whether it was given in seconds or as a date. It is
+	// zero for other status codes and when there is no such header.
+	RetryAfter time.Duration
+	// Method and URL are those of the request the response answered,
+	// when the response says what that was. The URL leaves off the
+	// query string and user info since those often hold secrets, like
+	// API keys, which have no business ending up in logs.
+	Method string
+	URL    string
+	// Failure is the error value the body was decoded into by
+	// JSONOrError. It is nil when the body could not be decoded or
+	// when some other function returned the error.
+	Failure interface{}
+
+	// previewSize is how much of Body the message shows, set with
+	// WithPreviewSize.
+	previewSize int
+}
+
+func (e *StatusError) Error() string {
+	msg := statusMismatch(e.StatusCode, e.WantStatuses)
+	if e.Method != "" || e.URL != "" {
+		msg = strings.TrimSpace(e.Method+" "+e.URL) + ": " + msg
+	}
+	if len(e.Header) > 0 {
+		names := make([]string, 0, len(e.Header))
+		for name := range e.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		headers := make([]string, len(names))
+		for i, name := range names {
+			headers[i] = name + ": " + strings.Join(e.Header[name], ", ")
+		}
+		msg += " (" + strings.Join(headers, "; ") + ")"
+	}
+	if e.ReadErr != nil {
+		return fmt.Sprintf("%s, also an error occurred when reading the response body: %v", msg, e.ReadErr)
+	}
+	previewSize := e.previewSize
+	if previewSize == 0 {
+		previewSize = defaultPreviewSize
+	}
+	body, cut := previewBody(e.Body, previewSize)
+	if cut || e.Truncated {
+		return fmt.Sprintf("%s, the first %d bytes of the response body are: %s...", msg, len(body), body)
+	}
+	return fmt.Sprintf("%s, body: %s", msg, body)
+}
+
+// Is makes errors.Is(err, ErrUnexpectedStatus) true.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrUnexpectedStatus
+}
+
+// ReadError is returned when reading the response body fails, like
+// when the connection drops or the context is done before the whole
+// body arrived.
+type ReadError struct {
+	Err error
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("reading response body: %v", e.Err)
+}
+
+func (e *ReadError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeError is returned when the response body could not be
+// unmarshalled, or parsed as CSV, a form and the like.
+type DecodeError struct {
+	Err error
+	// Body is the beginning of the response body, as much of it as
+	// the message shows. It is nil when the message does not show the
+	// body.
+	Body []byte
+	// Truncated reports whether Body is only the beginning of what
+	// was read.
+	Truncated bool
+	// doing is what the message says failed, "unmarshalling response
+	// body" when it is empty.
+	doing string
+}
+
+func (e *DecodeError) Error() string {
+	doing := e.doing
+	if doing == "" {
+		doing = "unmarshalling response body"
+	}
+	if e.Body == nil {
+		return fmt.Sprintf("%s: %v", doing, e.Err)
+	}
+	return fmt.Sprintf("%s: %v; first %d bytes were: %s%s", doing, e.Err, len(e.Body), e.Body, ellipsis(e.Truncated))
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// statusError finishes off err according to the options: the headers
+// asked for with WithErrorHeaders are captured and the classifier set
+// with WithErrorClassifier gets a chance to turn it into a domain error.
+func (c *config) statusError(resp *http.Response, err *StatusError) error {
+	if c.previewSize != defaultPreviewSize {
+		err.previewSize = c.previewSize
+	}
+	if err.StatusCode == http.StatusTooManyRequests || err.StatusCode == http.StatusServiceUnavailable {
+		err.RetryAfter = retryAfter(resp, 0)
+	}
+	if req := resp.Request; req != nil {
+		err.Method = req.Method
+		if req.URL != nil {
+			u := *req.URL
+			u.User, u.RawQuery, u.ForceQuery, u.Fragment, u.RawFragment = nil, "", false, "", ""
+			err.URL = u.String()
+		}
+	}
+	for _, name := range c.errorHeaders {
+		if values := resp.Header.Values(name); len(values) > 0 {
+			if err.Header == nil {
+				err.Header = http.Header{}
+			}
+			err.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	if c.classify == nil || err.ReadErr != nil {
+		return err
+	}
+	if classified := c.classify(err.StatusCode, err.Body); classified != nil {
+		return classified
+	}
+	return err
+}
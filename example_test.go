@@ -1,10 +1,12 @@
 package httpparse_test
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/lag13/httpparse"
 )
@@ -32,7 +34,7 @@ func ExampleJSON() {
 		StatusCode: http.StatusOK,
 		Body:       ioutil.NopCloser(strings.NewReader(`{"field1":"hello there", "field2":42}`)),
 	}
-	if err := httpparse.JSON(resp, http.StatusOK, &structuredBody); err != nil {
+	if err := httpparse.JSON(resp, []int{http.StatusOK}, &structuredBody); err != nil {
 		fmt.Println("got error:", err)
 	}
 	fmt.Println("field1 is:", structuredBody.Field1)
@@ -41,3 +43,31 @@ func ExampleJSON() {
 	// Output: field1 is: hello there
 	// field2 is: 42
 }
+
+func ExampleWithCommentHook() {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       ioutil.NopCloser(strings.NewReader(": ping\n\n: ping\n\ndata: hello there\n\n")),
+	}
+	// Give up on the stream if the server goes quiet for too long,
+	// counting heartbeats as a sign of life.
+	idle := time.AfterFunc(time.Minute, func() { resp.Body.Close() })
+	defer idle.Stop()
+	heartbeats := 0
+	err := httpparse.Events(context.Background(), resp, http.StatusOK, func(e httpparse.Event) error {
+		idle.Reset(time.Minute)
+		fmt.Println("got event:", e.Data)
+		return nil
+	}, httpparse.WithCommentHook(func(comment string) {
+		idle.Reset(time.Minute)
+		heartbeats++
+	}))
+	if err != nil {
+		fmt.Println("got error:", err)
+	}
+	fmt.Println("heartbeats:", heartbeats)
+
+	// Output: got event: hello there
+	// heartbeats: 2
+}
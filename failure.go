@@ -0,0 +1,35 @@
+package httpparse
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// JSONOrError is for APIs which respond with a structured JSON error
+// (e.g. {"error":{"code":...,"message":...}}) when things go wrong.
+// When the status code is wantStatus the body is decoded into success
+// just like JSON would. Otherwise the body is decoded into failure and
+// the returned *StatusError has its Failure field set to failure so
+// the status code and the decoded error can be inspected together:
+//
+//	var apiErr APIError
+//	err := httpparse.JSONOrError(resp, 200, &user, &apiErr)
+//	var se *httpparse.StatusError
+//	if errors.As(err, &se) && se.Failure != nil {
+//		// apiErr holds the decoded error
+//	}
+//
+// If the error body is not JSON, like an HTML page from a proxy, you
+// get the same *StatusError JSON would return with the raw body in it.
+func JSONOrError(resp *http.Response, wantStatus int, success interface{}, failure interface{}) error {
+	err := JSON(resp, []int{wantStatus}, success)
+	var se *StatusError
+	if !errors.As(err, &se) || se.ReadErr != nil || se.Truncated {
+		return err
+	}
+	if json.Unmarshal(se.Body, failure) == nil {
+		se.Failure = failure
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+type apiError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// TestJSONOrError tests that error responses are decoded into a
+// different type than successful ones.
+func TestJSONOrError(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		wantData    structuredJSON
+		wantFailure *apiError
+		wantErr     string
+	}{
+		{
+			name: "success",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name: "error when unmarshalling a successful response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`lats`)),
+			},
+			wantErr: "unmarshalling response body: invalid character 'l'",
+		},
+		{
+			name: "structured error",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"error":{"code":12,"message":"bad name"}}`)),
+			},
+			wantFailure: func() *apiError {
+				var e apiError
+				e.Error.Code = 12
+				e.Error.Message = "bad name"
+				return &e
+			}(),
+			wantErr: `got status code 400 but wanted 200, body: {"error":{"code":12,"message":"bad name"}}`,
+		},
+		{
+			name: "error which is not JSON",
+			resp: &http.Response{
+				StatusCode: 502,
+				Body:       ioutil.NopCloser(strings.NewReader(`<html>Bad Gateway</html>`)),
+			},
+			wantErr: "got status code 502 but wanted 200, body: <html>Bad Gateway</html>",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			var failure apiError
+			err := httpparse.JSONOrError(test.resp, 200, &data, &failure)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			var se *httpparse.StatusError
+			if errors.As(err, &se) && se.Failure != nil {
+				if test.wantFailure == nil {
+					t.Fatalf("got failure %+v, wanted none", se.Failure)
+				}
+				if got, want := failure, *test.wantFailure; got != want {
+					t.Errorf("got failure %+v, wanted %+v", got, want)
+				}
+			} else if test.wantFailure != nil {
+				t.Errorf("got no failure, wanted %+v", *test.wantFailure)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package httpparse
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// Form parses a http response who's body is
+// application/x-www-form-urlencoded, like those from some OAuth token
+// endpoints. The body is read just like RawBody reads it.
+func Form(resp *http.Response, wantStatus int) (url.Values, error) {
+	body, err := RawBody(resp, []int{wantStatus})
+	if err != nil {
+		return nil, err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, &DecodeError{Err: err, doing: "parsing form-encoded response body"}
+	}
+	return values, nil
+}
+
+// FormInto parses a http response who's body is
+// application/x-www-form-urlencoded into the struct v points to, just
+// like Form does. A key is stored in the field with a matching form tag,
+// e.g. `form:"access_token"`, or in an untagged field of the same name.
+// Fields tagged `form:"-"` are skipped, as are keys without a field.
+// Fields may be strings, bools, integers, floats or slices of those,
+// slices get all the values of their key and everything else gets the
+// first one.
+func FormInto(resp *http.Response, wantStatus int, v interface{}) error {
+	values, err := Form(resp, wantStatus)
+	if err != nil {
+		return err
+	}
+	if err := formInto(values, v); err != nil {
+		return &DecodeError{Err: err, doing: "parsing form-encoded response body"}
+	}
+	return nil
+}
+
+// formInto stores values in the struct v points to as described by
+// FormInto.
+func formInto(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("need a non-nil pointer to a struct, got %T", v)
+	}
+	rv = rv.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("form"); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setFormField(rv.Field(i), vals); err != nil {
+			return fmt.Errorf("field %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setFormField stores vals in the field f.
+func setFormField(f reflect.Value, vals []string) error {
+	if f.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(f.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		f.Set(s)
+		return nil
+	}
+	return setValue(f, vals[0])
+}
+
+// setValue stores the single value val in f, converting it to f's
+// type.
+func setValue(f reflect.Value, val string) error {
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(val)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(val, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(val, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(val, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestForm tests that form-encoded response bodies are parsed.
+func TestForm(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		wantValues url.Values
+		wantErr    string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 401,
+				Body:       ioutil.NopCloser(strings.NewReader("error=invalid_client")),
+			},
+			wantValues: nil,
+			wantErr:    "got status code 401 but wanted 200, body: error=invalid_client",
+		},
+		{
+			name: "malformed body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("access_token=abc%zz")),
+			},
+			wantValues: nil,
+			wantErr:    `parsing form-encoded response body: invalid URL escape "%zz"`,
+		},
+		{
+			name: "parsed body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("access_token=abc%20123&scope=read&scope=write")),
+			},
+			wantValues: url.Values{"access_token": {"abc 123"}, "scope": {"read", "write"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values, err := httpparse.Form(test.resp, 200)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := values, test.wantValues; !reflect.DeepEqual(got, want) {
+				t.Errorf("got values %v, wanted %v", got, want)
+			}
+		})
+	}
+}
+
+type tokenForm struct {
+	AccessToken string   `form:"access_token"`
+	ExpiresIn   int      `form:"expires_in"`
+	Scope       []string `form:"scope"`
+	Refreshable bool
+	Ignored     string `form:"-"`
+}
+
+// TestFormInto tests that form-encoded response bodies are parsed into
+// structs.
+func TestFormInto(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		wantData tokenForm
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 401,
+				Body:       ioutil.NopCloser(strings.NewReader("error=invalid_client")),
+			},
+			wantErr: "got status code 401 but wanted 200, body: error=invalid_client",
+		},
+		{
+			name: "value of the wrong type",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("access_token=abc&expires_in=soon")),
+			},
+			wantData: tokenForm{AccessToken: "abc"},
+			wantErr:  `parsing form-encoded response body: field expires_in: strconv.ParseInt: parsing "soon": invalid syntax`,
+		},
+		{
+			name: "parsed body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("access_token=abc&expires_in=3600&scope=read&scope=write&Refreshable=true&Ignored=x&other=y")),
+			},
+			wantData: tokenForm{AccessToken: "abc", ExpiresIn: 3600, Scope: []string{"read", "write"}, Refreshable: true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data tokenForm
+			err := httpparse.FormInto(test.resp, 200, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; !reflect.DeepEqual(got, want) {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
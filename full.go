@@ -0,0 +1,44 @@
+package httpparse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// FullResult is the decoded value of a response along with the
+// metadata of the response it came from.
+type FullResult[T any] struct {
+	Value       T
+	StatusCode  int
+	Header      http.Header
+	ContentType string
+	// Size is the length of the body in bytes.
+	Size int64
+	// BodySHA256 is the hex encoded SHA-256 hash of the body.
+	BodySHA256 string
+}
+
+// Full parses a http response who's body contains JSON into a T, just
+// like JSON does, and returns it together with everything a client
+// might want to persist about the response. The body is kept in memory
+// as it is decoded (see JSONWithBody) so it can be measured and hashed,
+// which makes it more memory hungry than JSON, especially for large
+// bodies. Size and BodySHA256 are those of the body as it was decoded,
+// i.e. decompressed and in UTF-8.
+func Full[T any](resp *http.Response, wantStatus int, opts ...Option) (FullResult[T], error) {
+	var value T
+	body, err := JSONWithBody(resp, []int{wantStatus}, &value, opts...)
+	if err != nil {
+		return FullResult[T]{}, err
+	}
+	sum := sha256.Sum256(body)
+	return FullResult[T]{
+		Value:       value,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		ContentType: resp.Header.Get("Content-Type"),
+		Size:        int64(len(body)),
+		BodySHA256:  hex.EncodeToString(sum[:]),
+	}, nil
+}
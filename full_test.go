@@ -0,0 +1,84 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestFull tests that the decoded value is returned along with the
+// response's metadata.
+func TestFull(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		opts       []httpparse.Option
+		wantResult httpparse.FullResult[structuredJSON]
+		wantErr    string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantResult: httpparse.FullResult[structuredJSON]{},
+			wantErr:    "got status code 999 but wanted 200, body: woa there",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("lats")),
+			},
+			wantResult: httpparse.FullResult[structuredJSON]{},
+			wantErr:    "unmarshalling response body: invalid character 'l' looking for beginning of value; first 4 bytes were: lats",
+		},
+		{
+			name: "options are applied",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there","other":1}`)),
+			},
+			opts:       []httpparse.Option{httpparse.WithStrictJSON()},
+			wantResult: httpparse.FullResult[structuredJSON]{},
+			wantErr:    `unknown field "other"`,
+		},
+		{
+			name: "got everything",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there"}`)),
+			},
+			wantResult: httpparse.FullResult[structuredJSON]{
+				Value:       structuredJSON{ValueOne: "hello there"},
+				StatusCode:  200,
+				Header:      http.Header{"Content-Type": {"application/json"}},
+				ContentType: "application/json",
+				Size:        27,
+				BodySHA256:  "74b20e5c99b5cbc28d00e64fff57527223b8c51613df25cd938a185bd90519c0",
+			},
+			wantErr: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := httpparse.Full[structuredJSON](test.resp, 200, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := res, test.wantResult; !reflect.DeepEqual(got, want) {
+				t.Errorf("got result %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
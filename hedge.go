@@ -0,0 +1,102 @@
+package httpparse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// send performs req with client and ctx, hedging it when WithHedge says
+// so, and tells the breaker how it went. This is what Do and
+// DoWithRetry perform their requests with.
+func (c *config) send(ctx context.Context, client *http.Client, req *http.Request) (resp *http.Response, err error) {
+	defer func() { c.record(resp, err) }()
+	if c.hedgeDelay <= 0 || !retryable(req) {
+		return client.Do(req.WithContext(ctx))
+	}
+	return hedge(ctx, client, req, c.hedgeDelay)
+}
+
+// hedgeResult is the outcome of one of the requests hedge performs.
+type hedgeResult struct {
+	i    int
+	resp *http.Response
+	err  error
+}
+
+// hedge performs req and, if there is no response after delay,
+// performs it a second time. The first response to arrive wins and the
+// other request is cancelled. It is an error only when every request
+// which was performed failed, the error being that of the first one to
+// fail.
+func hedge(ctx context.Context, client *http.Client, req *http.Request, delay time.Duration) (*http.Response, error) {
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		i := len(cancels)
+		rctx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		r, err := retryRequest(rctx, req, i+1)
+		if err != nil {
+			results <- hedgeResult{i: i, err: err}
+			return
+		}
+		go func() {
+			resp, err := client.Do(r)
+			results <- hedgeResult{i: i, resp: resp, err: err}
+		}()
+	}
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	var firstErr error
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			launch()
+			pending++
+		case res := <-results:
+			pending--
+			if res.err != nil {
+				if firstErr == nil {
+					firstErr = res.err
+				}
+				continue
+			}
+			// Whichever request is still going has lost. Should
+			// its response arrive anyway it is thrown away.
+			for i, cancel := range cancels {
+				if i != res.i {
+					cancel()
+				}
+			}
+			go func(pending int) {
+				for ; pending > 0; pending-- {
+					if res := <-results; res.err == nil {
+						res.resp.Body.Close()
+					}
+				}
+			}(pending)
+			res.resp.Body = cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.i]}
+			return res.resp, nil
+		}
+	}
+	for _, cancel := range cancels {
+		cancel()
+	}
+	return nil, firstErr
+}
+
+// cancelOnClose cancels the context of a hedged request once the body
+// of its response is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
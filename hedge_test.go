@@ -0,0 +1,111 @@
+package httpparse_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestHedge tests that a slow request is hedged with a second one.
+func TestHedge(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		requests  int
+		cancelled = make(chan bool, 2)
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+		// The first request hangs until it is cancelled, the
+		// second one answers right away.
+		if n == 1 && r.URL.Path != "/fast" {
+			select {
+			case <-r.Context().Done():
+				cancelled <- true
+			case <-time.After(5 * time.Second):
+				cancelled <- false
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"value_one":"request %d"}`, n)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		opts         []httpparse.Option
+		wantRequests int
+		wantData     structuredJSON
+		wantErr      string
+	}{
+		{
+			name:         "fast response is not hedged",
+			method:       "GET",
+			path:         "/fast",
+			opts:         []httpparse.Option{httpparse.WithHedge(time.Second)},
+			wantRequests: 1,
+			wantData:     structuredJSON{ValueOne: "request 1"},
+		},
+		{
+			name:         "slow response is hedged",
+			method:       "GET",
+			path:         "/slow",
+			opts:         []httpparse.Option{httpparse.WithHedge(20 * time.Millisecond)},
+			wantRequests: 2,
+			wantData:     structuredJSON{ValueOne: "request 2"},
+		},
+		{
+			name:         "non-idempotent requests are not hedged",
+			method:       "POST",
+			path:         "/slow",
+			opts:         []httpparse.Option{httpparse.WithHedge(20 * time.Millisecond)},
+			wantRequests: 1,
+			wantErr:      "performing request: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mu.Lock()
+			requests = 0
+			mu.Unlock()
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			req, err := http.NewRequest(test.method, srv.URL+test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var data structuredJSON
+			err = httpparse.Do(ctx, nil, req, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if test.path == "/slow" {
+				if !<-cancelled {
+					t.Errorf("the slow request was not cancelled")
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if got, want := requests, test.wantRequests; got != want {
+				t.Errorf("got %d requests, wanted %d", got, want)
+			}
+		})
+	}
+}
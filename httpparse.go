@@ -19,7 +19,7 @@ and even if this package is not used it serves as a nice reference.
 package httpparse
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -35,9 +35,63 @@ func contains(xs []int, y int) bool {
 	return false
 }
 
+// tooLargeError is the error returned when a response body is bigger
+// than the read limit, it is ErrBodyTooLarge as far as errors.Is is
+// concerned.
+type tooLargeError struct {
+	maxBytes int64
+	// compressed is set when it was the compressed body which was too
+	// large and decompressed when the body was too large once
+	// decompressed.
+	compressed   bool
+	decompressed bool
+}
+
+func (e tooLargeError) Error() string {
+	if e.compressed {
+		return fmt.Sprintf("The compressed response body contained more than the limit of %d bytes. Either increase the limit or parse the response body another way", e.maxBytes)
+	}
+	if e.decompressed {
+		return fmt.Sprintf("The response body decompressed into more than the limit of %d bytes, a small body which decompresses into a huge one may be a decompression bomb. Either increase the limit or parse the response body another way", e.maxBytes)
+	}
+	return fmt.Sprintf("ioutil.ReadAll() is used to read the response body and we limit how much it can read because nothing is infinite. The response body contained more than the limit of %d bytes. Either increase the limit or parse the response body another way", e.maxBytes)
+}
+
+func (e tooLargeError) Is(target error) bool {
+	return target == ErrBodyTooLarge
+}
+
+// statusMismatch describes a status code which was not one of those
+// wanted.
+func statusMismatch(got int, wants []int) string {
+	if len(wants) == 0 {
+		return fmt.Sprintf("got unexpected status code %d", got)
+	}
+	if len(wants) == 1 {
+		return fmt.Sprintf("got status code %d but wanted %d", got, wants[0])
+	}
+	return fmt.Sprintf("got status code %d but wanted one of %v", got, wants)
+}
+
 // RawBody returns the raw http body as a []byte and errors if
-// anything goes wrong. It also closes the response body.
-func RawBody(resp *http.Response, wantStatuses []int, readLimit ...int64) (body []byte, err error) {
+// anything goes wrong. It also closes the response body. A body with a
+// gzip or deflate Content-Encoding is decompressed. No more than the
+// read limit (30 MB unless WithReadLimit says otherwise) will be read.
+func RawBody(resp *http.Response, wantStatuses []int, opts ...Option) (body []byte, err error) {
+	return RawBodyContext(context.Background(), resp, wantStatuses, opts...)
+}
+
+// RawBodyN is RawBody but it also returns the number of bytes read from
+// the body (see WithBytesRead), even when there is an error.
+func RawBodyN(resp *http.Response, wantStatuses []int, opts ...Option) (body []byte, n int64, err error) {
+	body, err = RawBody(resp, wantStatuses, append(opts[:len(opts):len(opts)], WithBytesRead(&n))...)
+	return body, n, err
+}
+
+// rawBody holds the logic of RawBody. A status code is wanted when
+// accept says so, wantStatuses is only for the error message.
+func rawBody(resp *http.Response, accept func(int) bool, wantStatuses []int, opts []Option) (body []byte, err error) {
+	c := newConfig(opts)
 	// From what I've gathered, checking an error returned from
 	// closing a resource that you only read from (like a HTTP
 	// response body) never yields an actionable error:
@@ -48,7 +102,9 @@ func RawBody(resp *http.Response, wantStatuses []int, readLimit ...int64) (body
 	// error: https://golang.org/pkg/net/http/. If checking the
 	// error is not useful it kind of feels like it shouldn't even
 	// return an error, oh well.
-	defer resp.Body.Close()
+	// Whatever is left of the body gets drained first (see
+	// drainAndClose) so the connection can be reused.
+	defer drainAndClose(resp.Body, c.readLimit)
 	// People say that using ioutil.ReadAll() is not ideal
 	// (https://www.reddit.com/r/golang/comments/2cdu7s/how_do_i_avoid_using_ioutilreadall/,
 	// http://jmoiron.net/blog/crossing-streams-a-love-letter-to-ioreader/)
@@ -62,55 +118,102 @@ func RawBody(resp *http.Response, wantStatuses []int, readLimit ...int64) (body
 	// and in my experience those APIs never return large amounts
 	// of data. Just in case though I am limiting the amount of
 	// data that can be read. The default limit (30 MB) is
-	// arbitrary and can be changed if desired.
-	maxBytes := int64(1 << 20 * 30)
-	if len(readLimit) > 0 {
-		maxBytes = readLimit[0]
-	}
-	limitedReader := &io.LimitedReader{
-		R: resp.Body,
-		N: maxBytes + 1,
-	}
-	body, err = ioutil.ReadAll(limitedReader)
+	// arbitrary and can be changed with WithReadLimit.
+	//
+	// The limit applies to the decompressed body so that it still
+	// protects us from a small body which decompresses into a huge
+	// one.
+	r, err := bodyReader(resp, c)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %v", err)
+		return nil, err
 	}
-	if limitedReader.N <= 0 {
-		return nil, fmt.Errorf("ioutil.ReadAll() is used to read the response body and we limit how much it can read because nothing is infinite. The response body contained more than the limit of %d bytes. Either increase the limit or parse the response body another way", maxBytes)
+	body, err = readAll(r, c)
+	if err != nil {
+		return nil, err
 	}
-	if got, wants := resp.StatusCode, wantStatuses; !contains(wants, got) {
-		errStr := fmt.Sprintf("got status code %d but wanted one of %v", got, wants)
-		if len(wants) == 1 {
-			errStr = fmt.Sprintf("got status code %d but wanted %d", got, wants[0])
-		}
-		return nil, fmt.Errorf("%s, body: %s", errStr, body)
+	if got, wants := resp.StatusCode, wantStatuses; !accept(got) && !c.acceptsStatus(got) {
+		return nil, c.statusError(resp, &StatusError{StatusCode: got, WantStatuses: wants, Body: body})
 	}
 	return body, nil
 }
 
 // JSON parses a http response who's body contains JSON and closes the
-// response body. Most of the logic revolves around trying to produce
-// clear error messages when edge cases are hit.
-func JSON(resp *http.Response, wantStatus int, v interface{}) error {
-	defer resp.Body.Close()
-	if got, want := resp.StatusCode, wantStatus; got != want {
-		maxBytes := int64(1 << 20)
-		limitedReader := &io.LimitedReader{
-			R: resp.Body,
-			N: maxBytes + 1,
+// response body. The response's status code must be one of
+// wantStatuses. The body is decoded as it is read but, just like
+// RawBody, no more than the read limit (30 MB unless WithReadLimit says
+// otherwise) will be read and a body with a gzip or deflate
+// Content-Encoding is decompressed. A body in a charset other than
+// UTF-8, according to the Content-Type header, is transcoded to UTF-8
+// first. An empty body, like that of a 204 No Content, is not an error
+// and leaves v untouched. Most of the logic revolves around trying to
+// produce clear error messages when edge cases are hit.
+func JSON(resp *http.Response, wantStatuses []int, v interface{}, opts ...Option) error {
+	return JSONContext(context.Background(), resp, wantStatuses, v, opts...)
+}
+
+// parseBody holds the logic shared by the functions which decode the
+// response body as they read it. decode is only called once the status
+// code checks out and any error it returns is about unmarshalling.
+func parseBody(resp *http.Response, wantStatuses []int, opts []Option, decode func(r io.Reader, c *config) error) error {
+	c := newConfig(opts)
+	defer drainAndClose(resp.Body, c.readLimit)
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		return err
+	}
+	if got, wants := resp.StatusCode, wantStatuses; !contains(wants, got) && !c.acceptsStatus(got) {
+		if c.noContent && (got == http.StatusNoContent || got == http.StatusNotModified) {
+			return nil
 		}
-		err := fmt.Errorf("got status code %d but wanted %d", got, want)
-		body, readErr := ioutil.ReadAll(limitedReader)
-		if readErr != nil {
-			return fmt.Errorf("%v, also an error occurred when reading the response body: %v", err, readErr)
+		return mismatchError(resp, r, c, wants)
+	}
+	if c.contentType != "" {
+		if err := checkContentType(resp, c.contentType); err != nil {
+			body, _, readErr := readPreview(r)
+			if readErr != nil {
+				return fmt.Errorf("%v, also an error occurred when reading the response body: %v", err, readErr)
+			}
+			body, cut := previewBody(body, c.previewSize)
+			return fmt.Errorf("%v; body: %s%s", err, body, ellipsis(cut))
 		}
-		if limitedReader.N <= 0 {
-			return fmt.Errorf("%v, the first %d bytes of the response body are: %s", err, maxBytes, body)
+	}
+	if c.transcode {
+		dec, err := charsetDecoder(resp)
+		if err != nil {
+			return err
 		}
-		return fmt.Errorf("%v, body: %s", err, body)
+		if dec != nil {
+			r = dec.Reader(r)
+		}
+		// Even a UTF-8 charset says what the body is, so it is not
+		// transcoded again according to what the body says.
+		c.transcoded = contentTypeCharset(resp) != ""
 	}
-	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
-		return fmt.Errorf("unmarshalling response body: %v", err)
+	return decodeLimited(r, c, decode)
+}
+
+// mismatchError returns the error for an unwanted status code when
+// none of the body has been read yet.
+func mismatchError(resp *http.Response, r io.Reader, c *config, wants []int) error {
+	err := &StatusError{StatusCode: resp.StatusCode, WantStatuses: wants}
+	err.Body, err.Truncated, err.ReadErr = readPreview(r)
+	return c.statusError(resp, err)
+}
+
+// readPreview reads the beginning of a response body for inclusion in
+// an error message.
+func readPreview(r io.Reader) (body []byte, truncated bool, err error) {
+	maxBytes := int64(1 << 20)
+	limitedReader := &io.LimitedReader{
+		R: r,
+		N: maxBytes + 1,
+	}
+	body, err = ioutil.ReadAll(limitedReader)
+	if err != nil {
+		return nil, false, err
+	}
+	if limitedReader.N <= 0 {
+		return body[:maxBytes], true, nil
 	}
-	return nil
+	return body, false, nil
 }
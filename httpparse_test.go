@@ -1,6 +1,7 @@
 package httpparse_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -37,7 +38,7 @@ func TestGetRawBody(t *testing.T) {
 		name           string
 		resp           *http.Response
 		expectStatuses []int
-		readLimit      int64
+		opts           []httpparse.Option
 		wantBody       string
 		wantErr        string
 	}{
@@ -47,7 +48,6 @@ func TestGetRawBody(t *testing.T) {
 				Body: errReadCloser{readErr: errors.New("some read err")},
 			},
 			expectStatuses: nil,
-			readLimit:      0,
 			wantBody:       "",
 			wantErr:        "reading response body: some read err",
 		},
@@ -58,7 +58,6 @@ func TestGetRawBody(t *testing.T) {
 				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
 			},
 			expectStatuses: []int{200},
-			readLimit:      0,
 			wantBody:       "",
 			wantErr:        "got status code 999 but wanted 200, body: woa there",
 		},
@@ -69,7 +68,6 @@ func TestGetRawBody(t *testing.T) {
 				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
 			},
 			expectStatuses: []int{200, 888},
-			readLimit:      0,
 			wantBody:       "",
 			wantErr:        "got status code 999 but wanted one of [200 888], body: woa there",
 		},
@@ -80,10 +78,32 @@ func TestGetRawBody(t *testing.T) {
 				Body:       ioutil.NopCloser(strings.NewReader("a reeaaaallllly loooooooong responnnnnnssssseeeeee bodyyyyyyyy")),
 			},
 			expectStatuses: []int{400},
-			readLimit:      19,
+			opts:           []httpparse.Option{httpparse.WithReadLimit(19)},
 			wantBody:       "",
 			wantErr:        "ioutil.ReadAll() is used to read the response body and we limit how much it can read because nothing is infinite. The response body contained more than the limit of 19 bytes. Either increase the limit or parse the response body another way",
 		},
+		{
+			name: "a zero limit means no body at all",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader("a")),
+			},
+			expectStatuses: []int{400},
+			opts:           []httpparse.Option{httpparse.WithReadLimit(0)},
+			wantBody:       "",
+			wantErr:        "The response body contained more than the limit of 0 bytes",
+		},
+		{
+			name: "empty response body with a zero limit",
+			resp: &http.Response{
+				StatusCode: 204,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			},
+			expectStatuses: []int{204},
+			opts:           []httpparse.Option{httpparse.WithReadLimit(0)},
+			wantBody:       "",
+			wantErr:        "",
+		},
 		{
 			name: "returned raw response body",
 			resp: &http.Response{
@@ -91,20 +111,13 @@ func TestGetRawBody(t *testing.T) {
 				Body:       ioutil.NopCloser(strings.NewReader("hello there buddy")),
 			},
 			expectStatuses: []int{400},
-			readLimit:      0,
 			wantBody:       "hello there buddy",
 			wantErr:        "",
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			var body []byte
-			var err error
-			if test.readLimit == 0 {
-				body, err = httpparse.RawBody(test.resp, test.expectStatuses)
-			} else {
-				body, err = httpparse.RawBody(test.resp, test.expectStatuses, test.readLimit)
-			}
+			body, err := httpparse.RawBody(test.resp, test.expectStatuses, test.opts...)
 
 			if test.wantErr == "" && err != nil {
 				t.Errorf("got a non-nil error: %v", err)
@@ -128,12 +141,12 @@ type structuredJSON struct {
 // unmarshals the raw JSON into the provided value.
 func TestParseJSONResponse(t *testing.T) {
 	tests := []struct {
-		name         string
-		resp         *http.Response
-		expectStatus int
-		readLimit    int64
-		wantData     structuredJSON
-		wantErr      string
+		name           string
+		resp           *http.Response
+		expectStatuses []int
+		opts           []httpparse.Option
+		wantData       structuredJSON
+		wantErr        string
 	}{
 		{
 			name: "unexpected response status code",
@@ -141,10 +154,19 @@ func TestParseJSONResponse(t *testing.T) {
 				StatusCode: 999,
 				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
 			},
-			expectStatus: 200,
-			readLimit:    0,
-			wantData:     structuredJSON{},
-			wantErr:      "got status code 999 but wanted 200, body: woa there",
+			expectStatuses: []int{200},
+			wantData:       structuredJSON{},
+			wantErr:        "got status code 999 but wanted 200, body: woa there",
+		},
+		{
+			name: "unexpected status code when expecting multiple status codes",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			expectStatuses: []int{200, 201},
+			wantData:       structuredJSON{},
+			wantErr:        "got status code 999 but wanted one of [200 201], body: woa there",
 		},
 		{
 			name: "unexpected response status code and error reading response body",
@@ -152,10 +174,9 @@ func TestParseJSONResponse(t *testing.T) {
 				StatusCode: 999,
 				Body:       errReadCloser{readErr: errors.New("some read err")},
 			},
-			expectStatus: 200,
-			readLimit:    0,
-			wantData:     structuredJSON{},
-			wantErr:      "got status code 999 but wanted 200, also an error occurred when reading the response body: some read err",
+			expectStatuses: []int{200},
+			wantData:       structuredJSON{},
+			wantErr:        "got status code 999 but wanted 200, also an error occurred when reading the response body: some read err",
 		},
 		{
 			name: "unexpected response status code did not read all of response body",
@@ -163,10 +184,9 @@ func TestParseJSONResponse(t *testing.T) {
 				StatusCode: 999,
 				Body:       ioutil.NopCloser(strings.NewReader(strings.Repeat("z", 1<<20+1))),
 			},
-			expectStatus: 200,
-			readLimit:    0,
-			wantData:     structuredJSON{},
-			wantErr:      "got status code 999 but wanted 200, the first 1048576 bytes of the response body are: zzz",
+			expectStatuses: []int{200},
+			wantData:       structuredJSON{},
+			wantErr:        "got status code 999 but wanted 200, the first 512 bytes of the response body are: " + strings.Repeat("z", 512) + "...",
 		},
 		{
 			name: "error when unmarshalling response body",
@@ -174,10 +194,64 @@ func TestParseJSONResponse(t *testing.T) {
 				StatusCode: 400,
 				Body:       ioutil.NopCloser(strings.NewReader(`lats`)),
 			},
-			expectStatus: 400,
-			readLimit:    0,
-			wantData:     structuredJSON{},
-			wantErr:      "unmarshalling response body: invalid character 'l'",
+			expectStatuses: []int{400},
+			wantData:       structuredJSON{},
+			wantErr:        "unmarshalling response body: invalid character 'l'",
+		},
+		{
+			name: "error when unmarshalling response body shows the body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`<html>Bad Gateway</html>`)),
+			},
+			expectStatuses: []int{200},
+			wantData:       structuredJSON{},
+			wantErr:        "unmarshalling response body: invalid character '<' looking for beginning of value; first 24 bytes were: <html>Bad Gateway</html>",
+		},
+		{
+			name: "error when unmarshalling response body shows the beginning of a large body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`<html>` + strings.Repeat("z", 1000))),
+			},
+			expectStatuses: []int{200},
+			wantData:       structuredJSON{},
+			wantErr:        "; first 512 bytes were: <html>" + strings.Repeat("z", 506),
+		},
+		{
+			name: "response body exceeded the limit",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there", "value_two":42}`)),
+			},
+			expectStatuses: []int{400},
+			opts:           []httpparse.Option{httpparse.WithReadLimit(19)},
+			wantData:       structuredJSON{},
+			wantErr:        "ioutil.ReadAll() is used to read the response body and we limit how much it can read because nothing is infinite. The response body contained more than the limit of 19 bytes. Either increase the limit or parse the response body another way",
+		},
+		{
+			name: "response body is exactly the limit",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}`)),
+			},
+			expectStatuses: []int{400},
+			opts:           []httpparse.Option{httpparse.WithReadLimit(16)},
+			wantData:       structuredJSON{ValueTwo: 42},
+			wantErr:        "",
+		},
+		{
+			name: "got the structured data for one of multiple status codes",
+			resp: &http.Response{
+				StatusCode: 201,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there", "value_two":42}`)),
+			},
+			expectStatuses: []int{200, 201},
+			wantData: structuredJSON{
+				ValueOne: "hello there",
+				ValueTwo: 42,
+			},
+			wantErr: "",
 		},
 		{
 			name: "got the structured data",
@@ -185,8 +259,7 @@ func TestParseJSONResponse(t *testing.T) {
 				StatusCode: 400,
 				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there", "value_two":42}`)),
 			},
-			expectStatus: 400,
-			readLimit:    0,
+			expectStatuses: []int{400},
 			wantData: structuredJSON{
 				ValueOne: "hello there",
 				ValueTwo: 42,
@@ -197,7 +270,7 @@ func TestParseJSONResponse(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			var data structuredJSON
-			err := httpparse.JSON(test.resp, test.expectStatus, &data)
+			err := httpparse.JSON(test.resp, test.expectStatuses, &data, test.opts...)
 
 			if test.wantErr == "" && err != nil {
 				t.Errorf("got a non-nil error: %v", err)
@@ -210,3 +283,90 @@ func TestParseJSONResponse(t *testing.T) {
 		})
 	}
 }
+
+// TestParseEmptyJSONResponse tests that an empty body, as is sent
+// with a 204 No Content, is not an error.
+func TestParseEmptyJSONResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		opts       []httpparse.Option
+		wantErr    string
+	}{
+		{
+			name:       "no content",
+			statusCode: 204,
+			body:       "",
+		},
+		{
+			name:       "nothing but whitespace",
+			statusCode: 200,
+			body:       " \r\n\t\n",
+		},
+		{
+			name:       "empty body with a reviver",
+			statusCode: 200,
+			body:       "",
+			opts: []httpparse.Option{httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) {
+				return nil, errors.New("should not be called")
+			})},
+		},
+		{
+			name:       "truncated JSON",
+			statusCode: 200,
+			body:       `  {"value_one":"hi"`,
+			wantErr:    "unmarshalling response body: unexpected EOF",
+		},
+		{
+			name:       "empty body with an unwanted status code",
+			statusCode: 404,
+			body:       "",
+			wantErr:    "got status code 404 but wanted one of [200 204], body: ",
+		},
+		{
+			name:       "not modified",
+			statusCode: 304,
+			body:       "",
+			wantErr:    "got status code 304 but wanted one of [200 204], body: ",
+		},
+		{
+			name:       "not modified with WithNoContent",
+			statusCode: 304,
+			body:       "",
+			opts:       []httpparse.Option{httpparse.WithNoContent()},
+		},
+		{
+			name:       "body of a not modified is not decoded with WithNoContent",
+			statusCode: 304,
+			body:       "not JSON",
+			opts:       []httpparse.Option{httpparse.WithNoContent()},
+		},
+		{
+			name:       "other unwanted status codes with WithNoContent",
+			statusCode: 404,
+			body:       "",
+			opts:       []httpparse.Option{httpparse.WithNoContent()},
+			wantErr:    "got status code 404 but wanted one of [200 204], body: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			data := structuredJSON{ValueOne: "untouched"}
+			err := httpparse.JSON(resp, []int{200, 204}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, (structuredJSON{ValueOne: "untouched"}); test.wantErr == "" && got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
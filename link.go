@@ -0,0 +1,154 @@
+package httpparse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Link is one link of a Link header (RFC 8288).
+type Link struct {
+	// URL is the link's target, resolved against the URL of the
+	// request when the response has one.
+	URL string
+	// Rel is the link's relation type, like "next", lower cased.
+	Rel string
+	// Params are the link's other parameters, like "title", keyed by
+	// their lower cased names.
+	Params map[string]string
+}
+
+// Links are the links of a response, in the order they appeared.
+type Links []Link
+
+// Get returns the first link with the given relation type.
+func (l Links) Get(rel string) (Link, bool) {
+	rel = strings.ToLower(rel)
+	for _, link := range l {
+		if link.Rel == rel {
+			return link, true
+		}
+	}
+	return Link{}, false
+}
+
+// ParseLinks parses the response's Link headers, as used for
+// pagination:
+//
+//	links, err := httpparse.ParseLinks(resp)
+//	if err != nil {
+//		return err
+//	}
+//	if next, ok := links.Get("next"); ok {
+//		// fetch next.URL
+//	}
+//
+// A link with several relation types, like rel="prev first", is
+// returned once for each of them and a link without any is left out.
+// No Link header means no links.
+func ParseLinks(resp *http.Response) (Links, error) {
+	var links Links
+	for _, header := range resp.Header.Values("Link") {
+		parsed, err := parseLinkHeader(header)
+		if err != nil {
+			return nil, fmt.Errorf("malformed Link header %q: %v", header, err)
+		}
+		for _, link := range parsed {
+			if resp.Request != nil && resp.Request.URL != nil {
+				if u, err := resp.Request.URL.Parse(link.URL); err == nil {
+					link.URL = u.String()
+				}
+			}
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+// parseLinkHeader parses the comma separated links of a single Link
+// header.
+func parseLinkHeader(s string) ([]Link, error) {
+	var links []Link
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return links, nil
+		}
+		if s[0] != '<' {
+			return nil, errors.New("expected '<'")
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return nil, errors.New("missing '>'")
+		}
+		target := s[1:end]
+		s = s[end+1:]
+		params := map[string]string{}
+		for {
+			s = strings.TrimLeft(s, " \t")
+			if s == "" || s[0] == ',' {
+				break
+			}
+			if s[0] != ';' {
+				return nil, fmt.Errorf("expected ';' but got %q", s[0])
+			}
+			var name, value string
+			var err error
+			name, value, s, err = parseLinkParam(s[1:])
+			if err != nil {
+				return nil, err
+			}
+			// Only the first occurrence of a parameter counts.
+			if _, ok := params[name]; !ok && name != "" {
+				params[name] = value
+			}
+		}
+		rels := strings.Fields(strings.ToLower(params["rel"]))
+		delete(params, "rel")
+		for _, rel := range rels {
+			link := Link{URL: target, Rel: rel, Params: map[string]string{}}
+			for k, v := range params {
+				link.Params[k] = v
+			}
+			links = append(links, link)
+		}
+	}
+}
+
+// parseLinkParam parses a single name=value parameter off the front of
+// s and returns what is left of s.
+func parseLinkParam(s string) (name, value, rest string, err error) {
+	s = strings.TrimLeft(s, " \t")
+	i := strings.IndexAny(s, "=;,")
+	if i < 0 {
+		i = len(s)
+	}
+	name = strings.ToLower(strings.TrimSpace(s[:i]))
+	s = s[i:]
+	if s == "" || s[0] != '=' {
+		return name, "", s, nil
+	}
+	s = strings.TrimLeft(s[1:], " \t")
+	if s == "" || s[0] != '"' {
+		i := strings.IndexAny(s, ";,")
+		if i < 0 {
+			i = len(s)
+		}
+		return name, strings.TrimSpace(s[:i]), s[i:], nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i++; i < len(s) {
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return name, b.String(), s[i+1:], nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", "", fmt.Errorf("unterminated quoted value of parameter %s", name)
+}
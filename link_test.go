@@ -0,0 +1,92 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestParseLinks tests that Link headers are parsed.
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		req       *http.Request
+		wantLinks httpparse.Links
+		wantErr   string
+	}{
+		{
+			name:      "no Link header",
+			header:    nil,
+			wantLinks: nil,
+		},
+		{
+			name: "pagination links",
+			header: http.Header{"Link": {
+				`<https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=1>; rel="prev first"`,
+				`<https://api.example.com/items?page=9>; REL=last; title="The \"end\""`,
+			}},
+			wantLinks: httpparse.Links{
+				{URL: "https://api.example.com/items?page=3", Rel: "next", Params: map[string]string{}},
+				{URL: "https://api.example.com/items?page=1", Rel: "prev", Params: map[string]string{}},
+				{URL: "https://api.example.com/items?page=1", Rel: "first", Params: map[string]string{}},
+				{URL: "https://api.example.com/items?page=9", Rel: "last", Params: map[string]string{"title": `The "end"`}},
+			},
+		},
+		{
+			name:   "relative links are resolved against the request",
+			header: http.Header{"Link": {`</items?page=2>; rel="next"`}},
+			req:    newRequest("GET", "https://api.example.com/v1/items?page=1"),
+			wantLinks: httpparse.Links{
+				{URL: "https://api.example.com/items?page=2", Rel: "next", Params: map[string]string{}},
+			},
+		},
+		{
+			name:      "link without a rel is left out",
+			header:    http.Header{"Link": {`<https://example.com/style.css>; type="text/css"`}},
+			wantLinks: nil,
+		},
+		{
+			name:    "missing angle brackets",
+			header:  http.Header{"Link": {`https://example.com; rel=next`}},
+			wantErr: `malformed Link header "https://example.com; rel=next": expected '<'`,
+		},
+		{
+			name:    "unterminated quote",
+			header:  http.Header{"Link": {`<https://example.com>; rel="next`}},
+			wantErr: "unterminated quoted value of parameter rel",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			links, err := httpparse.ParseLinks(&http.Response{Header: test.header, Request: test.req})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := links, test.wantLinks; !reflect.DeepEqual(got, want) {
+				t.Errorf("got links %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestLinksGet tests that links are looked up by relation type.
+func TestLinksGet(t *testing.T) {
+	links := httpparse.Links{
+		{URL: "https://example.com/2", Rel: "next"},
+		{URL: "https://example.com/9", Rel: "last"},
+	}
+	if link, ok := links.Get("Next"); !ok || link.URL != "https://example.com/2" {
+		t.Errorf("got link %+v and %t, wanted the next link", link, ok)
+	}
+	if link, ok := links.Get("prev"); ok {
+		t.Errorf("got link %+v, wanted none", link)
+	}
+}
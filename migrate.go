@@ -0,0 +1,31 @@
+package httpparse
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// JSONMigrate handles APIs whose response schema changes between
+// versions. The body is read just like RawBody would and the value of
+// the versionHeader picks which of the migrators turns the raw body
+// into the caller's canonical type, which is returned. Typically a
+// migrator unmarshals into a version specific struct and converts
+// that. A migrator registered under the empty string handles responses
+// without the header. It is an error for no migrator to match the
+// version.
+func JSONMigrate(resp *http.Response, wantStatus int, versionHeader string, migrators map[string]func([]byte) (interface{}, error)) (interface{}, error) {
+	body, err := RawBody(resp, []int{wantStatus})
+	if err != nil {
+		return nil, err
+	}
+	version := resp.Header.Get(versionHeader)
+	migrate, ok := migrators[version]
+	if !ok {
+		return nil, fmt.Errorf("no migrator for response version %q found in the %s header", version, versionHeader)
+	}
+	v, err := migrate(body)
+	if err != nil {
+		return nil, fmt.Errorf("migrating version %q response body: %v", version, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,116 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONMigrate tests that the migrator matching the response's
+// version is used.
+func TestJSONMigrate(t *testing.T) {
+	migrators := map[string]func([]byte) (interface{}, error){
+		"1": func(body []byte) (interface{}, error) {
+			var v1 struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(body, &v1); err != nil {
+				return nil, err
+			}
+			return structuredJSON{ValueOne: v1.Name}, nil
+		},
+		"2": func(body []byte) (interface{}, error) {
+			var v2 structuredJSON
+			err := json.Unmarshal(body, &v2)
+			return v2, err
+		},
+		"3": func(body []byte) (interface{}, error) {
+			return nil, errors.New("not supported yet")
+		},
+	}
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		wantData interface{}
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantData: nil,
+			wantErr:  "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "unknown version",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Api-Version": {"4"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			},
+			wantData: nil,
+			wantErr:  `no migrator for response version "4" found in the Api-Version header`,
+		},
+		{
+			name: "missing version header",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			},
+			wantData: nil,
+			wantErr:  `no migrator for response version "" found in the Api-Version header`,
+		},
+		{
+			name: "migrator fails",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Api-Version": {"3"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{}`)),
+			},
+			wantData: nil,
+			wantErr:  `migrating version "3" response body: not supported yet`,
+		},
+		{
+			name: "old version is migrated",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Api-Version": {"1"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"name":"bob"}`)),
+			},
+			wantData: structuredJSON{ValueOne: "bob"},
+			wantErr:  "",
+		},
+		{
+			name: "current version",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Api-Version": {"2"}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"bob","value_two":42}`)),
+			},
+			wantData: structuredJSON{ValueOne: "bob", ValueTwo: 42},
+			wantErr:  "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := httpparse.JSONMigrate(test.resp, 200, "Api-Version", migrators)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Package msgpackparse parses http responses who's body contains
+// MessagePack. It lives apart from httpparse so that only those who
+// need MessagePack depend on a MessagePack library.
+package msgpackparse
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/lag13/httpparse"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack parses a http response who's body contains MessagePack (e.g.
+// application/msgpack) and closes the response body. It works just like
+// httpparse.JSON does, including the status code checks, read limit,
+// error messages and options, except that it uses
+// github.com/vmihailenco/msgpack/v5. Struct fields are matched using
+// their msgpack tags.
+func MsgPack(resp *http.Response, wantStatuses []int, v interface{}, opts ...httpparse.Option) error {
+	opts = append(opts[:len(opts):len(opts)], httpparse.WithExpectedStatuses(wantStatuses...), httpparse.WithDecoder(Decode))
+	return httpparse.NewParser(opts...).Decode(resp, v)
+}
+
+// Decode is a httpparse.DecodeFunc for MessagePack, for use with
+// httpparse.WithDecoder.
+func Decode(r io.Reader, v interface{}) error {
+	return msgpack.NewDecoder(r).Decode(v)
+}
@@ -0,0 +1,87 @@
+package msgpackparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+	"github.com/lag13/httpparse/msgpackparse"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type structuredMsgPack struct {
+	ValueOne string `msgpack:"value_one"`
+	ValueTwo int    `msgpack:"value_two"`
+}
+
+func marshal(v interface{}) string {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// TestMsgPack tests that parsing a http response with a MessagePack
+// body returns an error when expected and unmarshals it otherwise.
+func TestMsgPack(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredMsgPack
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 999 but wanted 200, body: woa there",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(marshal("just a string"))),
+			},
+			wantErr: "unmarshalling response body: msgpack:",
+		},
+		{
+			name: "response body exceeded the limit",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(marshal(structuredMsgPack{ValueOne: "hello there"}))),
+			},
+			opts:    []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr: "The response body contained more than the limit of 5 bytes",
+		},
+		{
+			name: "got the structured data",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(marshal(structuredMsgPack{ValueOne: "hello there", ValueTwo: 42}))),
+			},
+			wantData: structuredMsgPack{ValueOne: "hello there", ValueTwo: 42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredMsgPack
+			err := msgpackparse.MsgPack(test.resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package httpparse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Option configures optional behavior of the parsing functions. The
+// zero set of options always yields the package's default behavior.
+type Option func(*config)
+
+// defaultReadLimit is the most bytes of a response body which get
+// read unless WithReadLimit says otherwise.
+const defaultReadLimit = 1 << 20 * 30
+
+// config holds the settings that can be tweaked with Options.
+type config struct {
+	readLimit   int64
+	zipLimit    int64
+	contentType string
+	sniffGzip   bool
+	convertCase bool
+	strict      bool
+	useNumber   bool
+	noContent   bool
+	buffered    bool
+	bufferSize  int
+
+	noDuplicateKeys bool
+	maxDepth        int
+	maxTokens       int
+
+	localeNumbers bool
+	decimalSep    rune
+	thousandsSep  rune
+
+	envelope     bool
+	envelopeMeta interface{}
+
+	reviver     func(key string, value json.RawMessage) (json.RawMessage, error)
+	classify    func(status int, body []byte) error
+	bomHook     func(bom string)
+	commentHook func(comment string)
+
+	errorHeaders []string
+	bytesRead    *int64
+	previewSize  int
+	tee          io.Writer
+
+	progress      func(Progress)
+	progressEvery time.Duration
+
+	retryAttempts int
+	retryOn       []int
+	retryBase     time.Duration
+	retryMax      time.Duration
+	retryDecode   bool
+	hedgeDelay    time.Duration
+	breaker       Breaker
+
+	csvComma      rune
+	csvLenient    bool
+	csvLazyQuotes bool
+
+	wantStatuses  []int
+	statusMatcher func(code int) bool
+	decoder       DecodeFunc
+	jsonDecoder   DecodeFunc
+
+	// transcode is set by the functions which want the body turned
+	// into UTF-8 (and transcoded once the Content-Type's charset has
+	// been applied) and ctx by the ones which take a context, they are
+	// not options.
+	transcode  bool
+	transcoded bool
+	ctx        context.Context
+
+	// compressed is set by bodyReader when the body gets decompressed,
+	// it is how much of the compressed body has been read. pooled is
+	// the buffer RawBodyPooled reads the body into.
+	compressed *compressedReader
+	pooled     *bytes.Buffer
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		readLimit:   defaultReadLimit,
+		previewSize: defaultPreviewSize,
+		retryBase:   retryBackoff,
+		retryMax:    maxRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithReadLimit sets the maximum number of bytes which will be read
+// from a response body, reading more than that is an error. It exists
+// to protect the process from pathologically large bodies. Without
+// this option the limit is 30 MB. A limit of 0 really is 0, meaning the
+// body must be empty.
+func WithReadLimit(n int64) Option {
+	return func(c *config) {
+		c.readLimit = n
+	}
+}
+
+// WithCompressedReadLimit sets the maximum number of bytes of a
+// compressed response body which will be read, separately from the
+// read limit which applies to the decompressed body. Between the two, a
+// small body which decompresses into a huge one (a decompression bomb)
+// and a huge compressed body both get stopped early instead of eating
+// CPU and memory. It only applies when the body actually gets
+// decompressed. Without this option, or with a non-positive n, only the
+// read limit applies.
+func WithCompressedReadLimit(n int64) Option {
+	return func(c *config) {
+		c.zipLimit = n
+	}
+}
+
+// WithContentType makes the parser check that the response's
+// Content-Type header has the given media type, e.g.
+// "application/json", before decoding the body. A type with a
+// structured syntax suffix, like application/problem+json, counts as
+// application/json and a media type of just "+json" accepts any type
+// with that suffix. Without it a server which responds with an HTML
+// error page and a successful status code gets you a confusing syntax
+// error. With it you get an error saying what the Content-Type was
+// along with a preview of the body.
+func WithContentType(mediaType string) Option {
+	return func(c *config) {
+		c.contentType = mediaType
+	}
+}
+
+// WithGzipSniffing makes the parser check the first two bytes of the
+// response body for the gzip magic number (0x1f 0x8b) and
+// transparently decompress the body when it is found, regardless of
+// what the response headers say. This exists to rescue data from
+// misconfigured servers which gzip their responses but forget the
+// "Content-Encoding: gzip" header. It is opt-in because a body that
+// merely happens to start with those two bytes would be mangled. Any
+// read limits apply to the decompressed bytes.
+func WithGzipSniffing() Option {
+	return func(c *config) {
+		c.sniffGzip = true
+	}
+}
+
+// WithCaseConversion lets JSON keys written in snake_case, kebab-case
+// or camelCase be unmarshalled into struct fields which have no json
+// tag, saving you from tagging every field of a large schema. A key is
+// matched to an untagged field when the two are equal after ignoring
+// case, underscores and hyphens, so "user_name", "user-name" and
+// "userName" all end up in a field named UserName. Explicit tags take
+// precedence: a tagged field is only ever matched by its tag name and
+// a key which already matches a field is never converted. This
+// requires an extra pass over the decoded JSON so it is slower than
+// plain unmarshalling.
+func WithCaseConversion() Option {
+	return func(c *config) {
+		c.convertCase = true
+	}
+}
+
+// WithStrictJSON makes it an error for a JSON object to have a key
+// which does not match any field of the struct it is unmarshalled
+// into, instead of silently dropping it. That is handy for contract
+// tests which check that a response matches a struct exactly but it
+// also means your code breaks when the API adds a field, so think
+// twice before using it anywhere else.
+func WithStrictJSON() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// WithNoContent makes a 204 No Content or 304 Not Modified response a
+// success, leaving the value untouched, even when those status codes
+// are not among the wanted ones. It is for endpoints which answer with
+// either a body or nothing at all, like a conditional GET. An empty
+// body with a wanted status code is never an error so this is not
+// needed for that.
+func WithNoContent() Option {
+	return func(c *config) {
+		c.noContent = true
+	}
+}
+
+// WithNoDuplicateKeys makes it an error for a JSON object to have the
+// same key twice. encoding/json quietly keeps the last value while other
+// parsers keep the first, so two systems reading the same body can
+// disagree about what it says, which is exactly what request smuggling
+// and similar attacks exploit. Keys are compared exactly, as they
+// appear in the JSON.
+func WithNoDuplicateKeys() Option {
+	return func(c *config) {
+		c.noDuplicateKeys = true
+	}
+}
+
+// WithMaxDepth makes it an error for JSON objects and arrays to be
+// nested more than n levels deep, the outermost one being level 1. The
+// read limit keeps the body small but even a small body can be
+// thousands of levels deep, which is expensive to decode and may crash
+// code which walks it recursively. Zero, the default, means no limit.
+func WithMaxDepth(n int) Option {
+	return func(c *config) {
+		c.maxDepth = n
+	}
+}
+
+// WithMaxTokens makes it an error for JSON to have more than n tokens,
+// where every delimiter, key and value is one token. A body of a
+// million tiny values fits within the read limit but takes a lot more
+// memory to decode than its size suggests. Zero, the default, means no
+// limit.
+func WithMaxTokens(n int) Option {
+	return func(c *config) {
+		c.maxTokens = n
+	}
+}
+
+// WithEnvelope unwraps JSON bodies which come in an envelope like
+// {"data": ..., "error": ..., "meta": ...}, as many APIs send them. The
+// "data" member is decoded into the value you pass, just like a body
+// without an envelope would be. An "error" member which is not null is
+// returned as an *EnvelopeError instead. The "meta" member is decoded
+// into meta, unless meta is nil.
+func WithEnvelope(meta interface{}) Option {
+	return func(c *config) {
+		c.envelope = true
+		c.envelopeMeta = meta
+	}
+}
+
+// WithUseNumber makes JSON numbers decoded into an interface{} a
+// json.Number instead of a float64, see json.Decoder's UseNumber. A
+// float64 cannot hold every integer above 2^53 so without it large IDs,
+// like those of Twitter, silently come out as a different number.
+func WithUseNumber() Option {
+	return func(c *config) {
+		c.useNumber = true
+	}
+}
+
+// WithBOMHook calls fn whenever a JSON body starts with a byte order
+// mark, passing the encoding it stands for: "UTF-8", "UTF-16BE" or
+// "UTF-16LE". A BOM is not valid JSON but some servers (mostly those
+// running on Windows) send one anyway, so it is always stripped and a
+// UTF-16 body is transcoded to UTF-8. A UTF-8 BOM at the start of a CSV
+// body is stripped, and reported, too. This is for those who want to
+// know when that happens, e.g. to log a warning about the server.
+func WithBOMHook(fn func(bom string)) Option {
+	return func(c *config) {
+		c.bomHook = fn
+	}
+}
+
+// WithCommentHook calls fn with every comment line (one starting with
+// ":") in a stream parsed by Events, without the ":" and the space
+// after it. Servers send comments as heartbeats to keep an idle
+// connection open so this is for those who want to know the connection
+// is still healthy, e.g. to reset an idle timer.
+func WithCommentHook(fn func(comment string)) Option {
+	return func(c *config) {
+		c.commentHook = fn
+	}
+}
+
+// WithPreviewSize sets how many bytes of the body error messages show,
+// 512 by default. Anything after that is replaced with "...". This only
+// affects the messages, a *StatusError still holds the whole body (or
+// the first megabyte of it) in its Body field. A non-positive n keeps
+// the default.
+func WithPreviewSize(n int) Option {
+	return func(c *config) {
+		if n > 0 {
+			c.previewSize = n
+		}
+	}
+}
+
+// WithBytesRead makes the parser store the number of bytes it read from
+// the response body in *n, which is handy for recording response sizes
+// when the body is decoded as it is read and you never see it. For a
+// compressed body it is the number of compressed bytes, the ones which
+// came over the wire, not what they decompressed into. When the read
+// limit is exceeded by a body which is not compressed it is one more
+// than the limit. Bytes which were only read to drain the body before
+// closing it are not counted.
+func WithBytesRead(n *int64) Option {
+	return func(c *config) {
+		c.bytesRead = n
+	}
+}
+
+// WithProgress makes Download call fn as the body gets written, no more
+// than once every interval, and once more when it is done. A
+// non-positive interval means after every write. fn is called on the
+// goroutine doing the download so it should be quick about it.
+func WithProgress(interval time.Duration, fn func(Progress)) Option {
+	return func(c *config) {
+		c.progressEvery = interval
+		c.progress = fn
+	}
+}
+
+// WithTee makes the functions which decode a body as they read it,
+// like JSON, also write the body to w, so it can be cached, signed or
+// logged verbatim without reading it twice. It is the body as it is
+// decoded which gets written, so decompressed and, if its charset is
+// not UTF-8, transcoded. It is written in full, even the parts after
+// what got decoded. When the read limit is exceeded w gets no more than
+// one byte past it.
+func WithTee(w io.Writer) Option {
+	return func(c *config) {
+		c.tee = w
+	}
+}
+
+// WithBufferSize wraps the response body in a bufio.Reader of size n
+// before it is read. Decoding a large body in lots of small reads
+// means lots of syscalls, a bigger buffer means fewer of them. A
+// non-positive n gets the bufio package's default size. Without this
+// option the body is read as is.
+func WithBufferSize(n int) Option {
+	return func(c *config) {
+		c.buffered = true
+		c.bufferSize = n
+	}
+}
+
+// WithLocaleNumbers lets strings containing numbers written with
+// locale specific separators, like "3,14" or "1.234,5", be
+// unmarshalled into numeric struct fields. The thousands separators
+// are dropped and the decimal separator is replaced with a "." before
+// the string is treated as a number. Pass 0 as thousandsSep if the
+// API never uses one. Only strings headed for numeric fields are
+// touched and anything that is still not a number afterwards is left
+// alone (so unmarshalling fails just as it would without this option).
+//
+// Be aware that separators are inherently ambiguous: with decimalSep
+// ',' the string "1,234" is 1.234 but with thousandsSep ',' it is 1234.
+// Nothing in the JSON says which one the server meant so pick the
+// separators of the locale the API actually uses. Like
+// WithCaseConversion this requires an extra pass over the decoded JSON.
+func WithLocaleNumbers(decimalSep, thousandsSep rune) Option {
+	return func(c *config) {
+		c.localeNumbers = true
+		c.decimalSep = decimalSep
+		c.thousandsSep = thousandsSep
+	}
+}
+
+// WithReviver is inspired by the reviver argument of JavaScript's
+// JSON.parse(). Before a JSON body is unmarshalled, fn is called for
+// every value in it with the key it was found under (array elements
+// get their index and the top level value gets "") and whatever it
+// returns replaces that value. Values are visited depth first so by
+// the time fn sees an object or array its contents have already been
+// revived. Returning an empty value removes an object member (in an array
+// it becomes null). This makes it possible to decrypt particular
+// fields, normalize values or redact things as the body is parsed.
+//
+// Be aware that this requires a tokenizing pass which re-encodes every
+// value in the body and calls fn for each of them before the real
+// unmarshalling happens, so expect it to be several times slower than
+// plain decoding and to allocate a lot more.
+func WithReviver(fn func(key string, value json.RawMessage) (json.RawMessage, error)) Option {
+	return func(c *config) {
+		c.reviver = fn
+	}
+}
+
+// WithErrorClassifier routes responses with an unwanted status code
+// through fn so they can be turned into your own domain errors, like
+// an ErrNotFound for a 404 or a decoded API error for a 400. fn gets
+// the status code and (the beginning of) the body. Whatever non-nil
+// error it returns is returned in place of the usual *StatusError,
+// returning nil keeps the *StatusError. fn is not called if the body
+// could not be read.
+//
+// The point is to do the error translation for an API in one place, so
+// build the options once and pass them to every call:
+//
+//	var apiOpts = []httpparse.Option{httpparse.WithErrorClassifier(classifyAPIError)}
+//	err := httpparse.JSON(resp, []int{200}, &user, apiOpts...)
+func WithErrorClassifier(fn func(status int, body []byte) error) Option {
+	return func(c *config) {
+		c.classify = fn
+	}
+}
+
+// WithErrorHeaders makes the error for an unwanted status code include
+// the given response headers, e.g. Retry-After or X-Request-Id, which
+// are often the most useful thing to have when a request fails in
+// production. Headers the response does not have are left out. They
+// end up in the Header field of the *StatusError as well as in its
+// message:
+//
+//	got status code 429 but wanted 200 (Retry-After: 30; X-Request-Id: abc), body: slow down
+func WithErrorHeaders(names ...string) Option {
+	return func(c *config) {
+		c.errorHeaders = names
+	}
+}
+
+// WithRetry makes Retry and DoWithRetry issue the request again, up to
+// attempts times in total, while the response's status code is one of
+// on (e.g. 429, 502 and 503). The other functions ignore it since all
+// they have is a response.
+func WithRetry(attempts int, on []int) Option {
+	return func(c *config) {
+		c.retryAttempts = attempts
+		c.retryOn = on
+	}
+}
+
+// WithRetryBackoff sets how long DoWithRetry waits between attempts
+// when the response does not say: base before the first retry, twice
+// that before the second and so on, but never more than max. Each wait
+// is randomly shortened by up to half so that clients which failed
+// together do not all retry together. The default is 100ms doubling up
+// to 10s. max also caps the wait a Retry-After header may ask for with
+// Retry and DoWithRetry, a response asking for more is not retried.
+func WithRetryBackoff(base, max time.Duration) Option {
+	return func(c *config) {
+		c.retryBase = base
+		c.retryMax = max
+	}
+}
+
+// WithRetryOnDecodeError makes Do, DoJSON and DoWithRetry perform the
+// request once more when the response body cannot be decoded, in case
+// it got corrupted on the way, and parse that response instead. The
+// error from the second attempt is returned as is. Like retries, it
+// only applies to idempotent requests which can be replayed (see
+// DoWithRetry), so a POST is never sent twice.
+func WithRetryOnDecodeError() Option {
+	return func(c *config) {
+		c.retryDecode = true
+	}
+}
+
+// WithHedge makes Do, DoWithRetry and Cache.JSON perform the request a
+// second time when there is no response after delay and use whichever
+// response arrives first, cancelling the other request. That cuts the
+// time spent waiting on the occasional slow server at the cost of some
+// extra load, so pick a delay which only the slowest few percent of
+// requests take. Like retries, it only applies to idempotent requests
+// (see DoWithRetry).
+func WithHedge(delay time.Duration) Option {
+	return func(c *config) {
+		c.hedgeDelay = delay
+	}
+}
+
+// WithBreaker makes Do, DoWithRetry and Cache.JSON consult b, a circuit
+// breaker, before every attempt at a request and tell it how the
+// attempt went, so it can stop calls to a server which keeps failing
+// without the client having to be wrapped.
+func WithBreaker(b Breaker) Option {
+	return func(c *config) {
+		c.breaker = b
+	}
+}
+
+// WithCSVDelimiter sets the character separating the fields of a CSV
+// body, e.g. ';'. The default is ','.
+func WithCSVDelimiter(r rune) Option {
+	return func(c *config) {
+		c.csvComma = r
+	}
+}
+
+// WithCSVLenient lets the records of a CSV body have differing numbers
+// of fields. By default every record must have as many fields as the
+// first one.
+func WithCSVLenient() Option {
+	return func(c *config) {
+		c.csvLenient = true
+	}
+}
+
+// WithCSVLazyQuotes lets a quote appear in an unquoted field and a
+// non-doubled quote appear in a quoted field of a CSV body, see
+// csv.Reader's LazyQuotes.
+func WithCSVLazyQuotes() Option {
+	return func(c *config) {
+		c.csvLazyQuotes = true
+	}
+}
+
+// WithExpectedStatuses sets the status codes a Parser accepts, 200 if
+// it is not used. Passing none at all accepts no status code, just like
+// an empty list does for JSON, unless WithStatusMatcher says otherwise.
+// The functions which take the status codes as an argument ignore it.
+func WithExpectedStatuses(statuses ...int) Option {
+	if statuses == nil {
+		statuses = []int{}
+	}
+	return func(c *config) {
+		c.wantStatuses = statuses
+	}
+}
+
+// WithStatusMatcher makes every status code match accepts a wanted
+// one, on top of those listed, for when listing them all gets tedious.
+// Pass no status codes to rely on match alone:
+//
+//	err := httpparse.JSON(resp, nil, &user, httpparse.WithStatusMatcher(httpparse.Status2xx))
+//
+// See StatusRange and StatusNot for building matchers.
+func WithStatusMatcher(match func(code int) bool) Option {
+	return func(c *config) {
+		c.statusMatcher = match
+	}
+}
+
+// WithDecoder sets the function a Parser decodes bodies with, for
+// formats other than JSON. Without it bodies are decoded as JSON,
+// honoring the JSON options. The functions which decode a particular
+// format ignore it.
+func WithDecoder(decode DecodeFunc) Option {
+	return func(c *config) {
+		c.decoder = decode
+	}
+}
+
+// WithJSONDecoder swaps encoding/json for another JSON library when
+// decoding JSON bodies, for those who need something faster:
+//
+//	httpparse.WithJSONDecoder(func(r io.Reader, v interface{}) error {
+//		return jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r).Decode(v)
+//	})
+//
+// Options which massage the JSON first, like WithReviver, still do so
+// with encoding/json but the result is unmarshalled with decode. Since
+// decode does all of the unmarshalling, WithStrictJSON is ignored and
+// it is up to decode to be strict if that is wanted.
+func WithJSONDecoder(decode DecodeFunc) Option {
+	return func(c *config) {
+		c.jsonDecoder = decode
+	}
+}
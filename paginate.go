@@ -0,0 +1,96 @@
+package httpparse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Paginate walks through the pages of a paginated API which links to
+// the next page with a Link header, like GitHub does. It performs req
+// with client (http.DefaultClient if it is nil) and calls fn for the
+// page it gets back, then does the same for the page its
+// Link: <...>; rel="next" header points to and so on until a page has
+// no next link. decode parses the page into whatever you pass it just
+// like JSONContext would, with opts:
+//
+//	err := httpparse.Paginate(ctx, nil, req, 200, func(decode func(interface{}) error) error {
+//		var repos []Repo
+//		if err := decode(&repos); err != nil {
+//			return err
+//		}
+//		all = append(all, repos...)
+//		return nil
+//	})
+//
+// Returning an error from fn stops the pagination and that error is
+// returned as is, as are the errors parsing a page. A page whose status
+// code is not wantStatus stops it too, without calling fn, whether or
+// not fn would have decoded the page. The next pages are fetched with
+// GET and the headers of req, except that they are only sent along to
+// the same host req went to since they often hold credentials. A next
+// link which points back to a page that was already fetched is an
+// error rather than an endless loop.
+func Paginate(ctx context.Context, client *http.Client, req *http.Request, wantStatus int, fn func(decode func(v interface{}) error) error, opts ...Option) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := newConfig(opts)
+	seen := map[string]bool{req.URL.String(): true}
+	req = req.WithContext(ctx)
+	for {
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("performing request: %v", err)
+		}
+		// A page with the wrong status code never gets to fn, which
+		// would not notice if it does not decode the page.
+		if got := resp.StatusCode; got != wantStatus && !c.acceptsStatus(got) {
+			_, err := RawBodyContext(ctx, resp, []int{wantStatus}, opts...)
+			return err
+		}
+		links, err := ParseLinks(resp)
+		if err != nil {
+			resp.Body.Close()
+			return err
+		}
+		decoded := false
+		err = fn(func(v interface{}) error {
+			if decoded {
+				return fmt.Errorf("page %s has already been decoded", req.URL)
+			}
+			decoded = true
+			return JSONContext(ctx, resp, []int{wantStatus}, v, opts...)
+		})
+		if !decoded {
+			drainAndClose(resp.Body, c.readLimit)
+		}
+		if err != nil {
+			return err
+		}
+		next, ok := links.Get("next")
+		if !ok {
+			return nil
+		}
+		if seen[next.URL] {
+			return fmt.Errorf("next page %s was already fetched", next.URL)
+		}
+		seen[next.URL] = true
+		if req, err = nextPage(ctx, req, next.URL); err != nil {
+			return err
+		}
+	}
+}
+
+// nextPage returns the request for the page at url which follows the
+// page prev requested.
+func nextPage(ctx context.Context, prev *http.Request, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request for the next page: %v", err)
+	}
+	if req.URL.Host == prev.URL.Host {
+		req.Header = prev.Header.Clone()
+	}
+	return req, nil
+}
@@ -0,0 +1,127 @@
+package httpparse_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestPaginate tests that pages are fetched by following the next
+// links.
+func TestPaginate(t *testing.T) {
+	var auth []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = append(auth, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/items":
+			switch r.URL.Query().Get("page") {
+			case "":
+				w.Header().Set("Link", `</items?page=2>; rel="next"`)
+				fmt.Fprint(w, `[{"value_two":1},{"value_two":2}]`)
+			case "2":
+				w.Header().Set("Link", `</items?page=3>; rel="next", </items>; rel="first"`)
+				fmt.Fprint(w, `[{"value_two":3}]`)
+			case "3":
+				w.Header().Set("Link", `</items>; rel="first"`)
+				fmt.Fprint(w, `[{"value_two":4}]`)
+			}
+		case "/loop":
+			w.Header().Set("Link", `</loop>; rel="next"`)
+			fmt.Fprint(w, `[]`)
+		case "/broken":
+			w.Header().Set("Link", `</missing>; rel="next"`)
+			fmt.Fprint(w, `[]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "nope")
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		path      string
+		stopAt    int
+		noDecode  bool
+		wantItems []structuredJSON
+		wantAuth  []string
+		wantErr   string
+	}{
+		{
+			name:      "every page",
+			path:      "/items",
+			wantItems: []structuredJSON{{ValueTwo: 1}, {ValueTwo: 2}, {ValueTwo: 3}, {ValueTwo: 4}},
+			wantAuth:  []string{"token", "token", "token"},
+		},
+		{
+			name:      "callback stops early",
+			path:      "/items",
+			stopAt:    1,
+			wantItems: []structuredJSON{{ValueTwo: 1}, {ValueTwo: 2}},
+			wantAuth:  []string{"token", "token"},
+			wantErr:   "had enough",
+		},
+		{
+			name:     "next link loops",
+			path:     "/loop",
+			wantAuth: []string{"token"},
+			wantErr:  "next page " + srv.URL + "/loop was already fetched",
+		},
+		{
+			name:     "page with an unexpected status code",
+			path:     "/broken",
+			wantAuth: []string{"token", "token"},
+			wantErr:  "GET " + srv.URL + "/missing: got status code 404 but wanted 200, body: nope",
+		},
+		{
+			name:     "unexpected status code when the callback does not decode",
+			path:     "/broken",
+			noDecode: true,
+			wantAuth: []string{"token", "token"},
+			wantErr:  "got status code 404 but wanted 200, body: nope",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			auth = nil
+			req, _ := http.NewRequest("GET", srv.URL+test.path, nil)
+			req.Header.Set("Authorization", "token")
+			var items []structuredJSON
+			pages := 0
+			err := httpparse.Paginate(context.Background(), nil, req, 200, func(decode func(interface{}) error) error {
+				if test.stopAt != 0 && pages == test.stopAt {
+					return errors.New("had enough")
+				}
+				pages++
+				if test.noDecode {
+					return nil
+				}
+				var page []structuredJSON
+				if err := decode(&page); err != nil {
+					return err
+				}
+				items = append(items, page...)
+				return nil
+			})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := items, test.wantItems; !reflect.DeepEqual(got, want) {
+				t.Errorf("got items %+v, wanted %+v", got, want)
+			}
+			if got, want := auth, test.wantAuth; !reflect.DeepEqual(got, want) {
+				t.Errorf("got Authorization headers %q, wanted %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package httpparse
+
+import "net/http"
+
+// Parse is the escape hatch for formats this package knows nothing
+// about, like MessagePack or protobuf. The body is read just like
+// RawBody reads it, status code checks and read limit included, and
+// then handed to unmarshal. An error from unmarshal is returned in a
+// *DecodeError which reads "parsing response body: ...".
+func Parse(resp *http.Response, wantStatuses []int, unmarshal func(body []byte) error, opts ...Option) error {
+	body, err := RawBody(resp, wantStatuses, opts...)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(body); err != nil {
+		return &DecodeError{Err: err, doing: "parsing response body"}
+	}
+	return nil
+}
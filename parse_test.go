@@ -0,0 +1,86 @@
+package httpparse_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestParse tests that the body is handed to the unmarshal function.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		unmarshal func(v *structuredXML) func([]byte) error
+		opts      []httpparse.Option
+		wantData  structuredXML
+		wantErr   string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			unmarshal: func(v *structuredXML) func([]byte) error {
+				return func(body []byte) error { return errors.New("should not be called") }
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "response body too large",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			unmarshal: func(v *structuredXML) func([]byte) error {
+				return func(body []byte) error { return errors.New("should not be called") }
+			},
+			opts:    []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr: "The response body contained more than the limit of 5 bytes",
+		},
+		{
+			name: "unmarshal fails",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			unmarshal: func(v *structuredXML) func([]byte) error {
+				return func(body []byte) error { return fmt.Errorf("cannot parse %q", body) }
+			},
+			wantErr: `parsing response body: cannot parse "hello there"`,
+		},
+		{
+			name: "unmarshalled",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("<data><value_one>hi</value_one></data>")),
+			},
+			unmarshal: func(v *structuredXML) func([]byte) error {
+				return func(body []byte) error { return xml.Unmarshal(body, v) }
+			},
+			wantData: structuredXML{ValueOne: "hi"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredXML
+			err := httpparse.Parse(test.resp, []int{200}, test.unmarshal(&data), test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
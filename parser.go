@@ -0,0 +1,64 @@
+package httpparse
+
+import (
+	"io"
+	"net/http"
+)
+
+// DecodeFunc decodes the body read from r into v, like
+// xml.NewDecoder(r).Decode(v) does.
+type DecodeFunc func(r io.Reader, v interface{}) error
+
+// Parser parses responses according to options configured once, so
+// the same read limit, expected status codes and so on don't have to
+// be repeated at every call site:
+//
+//	p := httpparse.NewParser(
+//		httpparse.WithExpectedStatuses(200, 201),
+//		httpparse.WithReadLimit(1<<20),
+//	)
+//	err := p.Decode(resp, &user)
+//
+// Its methods work just like the functions of the same name. The zero
+// Parser is ready to use, it expects a 200 and decodes JSON. A Parser
+// is safe for concurrent use unless its options are not: every response
+// it parses writes to the same *int64 given to WithBytesRead and the
+// same io.Writer given to WithTee, and calls the same hooks, so a Parser
+// shared between goroutines should do without the former and only have
+// hooks which are safe to call concurrently.
+type Parser struct {
+	opts []Option
+}
+
+// NewParser returns a Parser configured with opts.
+func NewParser(opts ...Option) *Parser {
+	return &Parser{opts: opts}
+}
+
+// wantStatuses returns the status codes the parser accepts.
+func (p *Parser) wantStatuses() []int {
+	if statuses := newConfig(p.opts).wantStatuses; statuses != nil {
+		return statuses
+	}
+	return []int{http.StatusOK}
+}
+
+// RawBody returns the raw http body just like the RawBody function.
+func (p *Parser) RawBody(resp *http.Response) ([]byte, error) {
+	return RawBody(resp, p.wantStatuses(), p.opts...)
+}
+
+// Decode decodes the response body into v with the decoder set with
+// WithDecoder or, if there is none, as JSON just like the JSON function
+// would.
+func (p *Parser) Decode(resp *http.Response, v interface{}) error {
+	// Only JSON gets transcoded to UTF-8, other formats (like XML)
+	// may handle charsets themselves.
+	opts := append(p.opts[:len(p.opts):len(p.opts)], func(c *config) { c.transcode = c.decoder == nil })
+	return parseBody(resp, p.wantStatuses(), opts, func(r io.Reader, c *config) error {
+		if c.decoder != nil {
+			return c.decoder(r, v)
+		}
+		return decodeJSON(r, v, c)
+	})
+}
@@ -0,0 +1,123 @@
+package httpparse_test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestParser tests that a Parser applies its options to every
+// response it parses.
+func TestParser(t *testing.T) {
+	xmlDecoder := func(r io.Reader, v interface{}) error {
+		return xml.NewDecoder(r).Decode(v)
+	}
+	tests := []struct {
+		name     string
+		parser   *httpparse.Parser
+		resp     *http.Response
+		wantBody string
+		wantErr  string
+	}{
+		{
+			name:   "zero parser expects a 200",
+			parser: &httpparse.Parser{},
+			resp: &http.Response{
+				StatusCode: 201,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantErr: "got status code 201 but wanted 200, body: ",
+		},
+		{
+			name:   "zero parser decodes JSON",
+			parser: &httpparse.Parser{},
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantBody: "hi",
+		},
+		{
+			name:   "expected statuses",
+			parser: httpparse.NewParser(httpparse.WithExpectedStatuses(200, 201)),
+			resp: &http.Response{
+				StatusCode: 201,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantBody: "hi",
+		},
+		{
+			name:   "read limit",
+			parser: httpparse.NewParser(httpparse.WithReadLimit(5)),
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantErr: "The response body contained more than the limit of 5 bytes",
+		},
+		{
+			name:   "custom decoder",
+			parser: httpparse.NewParser(httpparse.WithDecoder(xmlDecoder)),
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`<data><value_one>hi</value_one></data>`)),
+			},
+			wantBody: "hi",
+		},
+		{
+			name:   "custom decoder fails",
+			parser: httpparse.NewParser(httpparse.WithDecoder(xmlDecoder)),
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantErr: "unmarshalling response body: EOF",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data struct {
+				ValueOne string `json:"value_one" xml:"value_one"`
+			}
+			err := test.parser.Decode(test.resp, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data.ValueOne, test.wantBody; got != want {
+				t.Errorf("got value %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestParserRawBody tests that a Parser reads raw bodies with its
+// options.
+func TestParserRawBody(t *testing.T) {
+	p := httpparse.NewParser(httpparse.WithExpectedStatuses(204), httpparse.WithReadLimit(5))
+	body, err := p.RawBody(&http.Response{
+		StatusCode: 204,
+		Body:       ioutil.NopCloser(strings.NewReader("hello")),
+	})
+	if err != nil {
+		t.Fatalf("got a non-nil error: %v", err)
+	}
+	if got, want := string(body), "hello"; got != want {
+		t.Errorf("got body %q, wanted %q", got, want)
+	}
+	_, err = p.RawBody(&http.Response{
+		StatusCode: 204,
+		Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+	})
+	if got, want := fmt.Sprintf("%v", err), "The response body contained more than the limit of 5 bytes"; !strings.Contains(got, want) {
+		t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+	}
+}
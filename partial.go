@@ -0,0 +1,78 @@
+package httpparse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ContentRange parses the Content-Range header of a 206 Partial
+// Content response, e.g. "bytes 0-499/1234", into the (inclusive)
+// positions of the first and last bytes received and the total size of
+// the resource. A total of -1 means the server does not know it (the
+// "bytes 0-499/*" form).
+func ContentRange(resp *http.Response) (start, end, total int64, err error) {
+	contentRange := resp.Header.Get("Content-Range")
+	if contentRange == "" {
+		return 0, 0, 0, errors.New("response has no Content-Range header")
+	}
+	malformed := func(reason string) error {
+		return fmt.Errorf("malformed Content-Range %q: %s", contentRange, reason)
+	}
+	spec := strings.TrimSpace(contentRange)
+	if !strings.HasPrefix(spec, "bytes ") {
+		return 0, 0, 0, malformed("unit is not bytes")
+	}
+	spec = strings.TrimSpace(strings.TrimPrefix(spec, "bytes "))
+	slash := strings.Index(spec, "/")
+	if slash < 0 {
+		return 0, 0, 0, malformed("missing the total size")
+	}
+	rng, size := spec[:slash], spec[slash+1:]
+	if rng == "*" {
+		return 0, 0, 0, malformed("range is unsatisfied")
+	}
+	dash := strings.Index(rng, "-")
+	if dash < 0 {
+		return 0, 0, 0, malformed("range has no end")
+	}
+	if start, err = strconv.ParseInt(rng[:dash], 10, 64); err != nil || start < 0 {
+		return 0, 0, 0, malformed("invalid start of range")
+	}
+	if end, err = strconv.ParseInt(rng[dash+1:], 10, 64); err != nil || end < start {
+		return 0, 0, 0, malformed("invalid end of range")
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil || total <= end {
+			return 0, 0, 0, malformed("invalid total size")
+		}
+	}
+	return start, end, total, nil
+}
+
+// PartialBody returns the body of a response to a range request along
+// with where it fits in the whole resource, which is what you need to
+// resume a download. A 206 Partial Content response must have a valid
+// Content-Range matching the length of the body. A 200 OK response
+// (the server ignored the range) is the whole resource. The body is
+// read just like RawBody would.
+func PartialBody(resp *http.Response, opts ...Option) (body []byte, start, end, total int64, err error) {
+	body, err = RawBody(resp, []int{http.StatusOK, http.StatusPartialContent}, opts...)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return body, 0, int64(len(body)) - 1, int64(len(body)), nil
+	}
+	start, end, total, err = ContentRange(resp)
+	if err != nil {
+		return nil, 0, 0, 0, err
+	}
+	if got, want := int64(len(body)), end-start+1; got != want {
+		return nil, 0, 0, 0, fmt.Errorf("got %d bytes but the Content-Range header says there should be %d", got, want)
+	}
+	return body, start, end, total, nil
+}
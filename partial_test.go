@@ -0,0 +1,130 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestContentRange tests that Content-Range headers are parsed.
+func TestContentRange(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentRange string
+		wantStart    int64
+		wantEnd      int64
+		wantTotal    int64
+		wantErr      string
+	}{
+		{name: "missing header", contentRange: "", wantErr: "response has no Content-Range header"},
+		{name: "known total", contentRange: "bytes 0-499/1234", wantStart: 0, wantEnd: 499, wantTotal: 1234},
+		{name: "unknown total", contentRange: "bytes 500-999/*", wantStart: 500, wantEnd: 999, wantTotal: -1},
+		{name: "not bytes", contentRange: "items 0-1/2", wantErr: `malformed Content-Range "items 0-1/2": unit is not bytes`},
+		{name: "no total", contentRange: "bytes 0-1", wantErr: "missing the total size"},
+		{name: "unsatisfied", contentRange: "bytes */1234", wantErr: "range is unsatisfied"},
+		{name: "no end", contentRange: "bytes 5/10", wantErr: "range has no end"},
+		{name: "bad start", contentRange: "bytes x-1/10", wantErr: "invalid start of range"},
+		{name: "end before start", contentRange: "bytes 5-1/10", wantErr: "invalid end of range"},
+		{name: "total too small", contentRange: "bytes 0-10/10", wantErr: "invalid total size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{"Content-Range": {test.contentRange}}}
+			start, end, total, err := httpparse.ContentRange(resp)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if start != test.wantStart || end != test.wantEnd || total != test.wantTotal {
+				t.Errorf("got range %d-%d/%d, wanted %d-%d/%d", start, end, total, test.wantStart, test.wantEnd, test.wantTotal)
+			}
+		})
+	}
+}
+
+// TestPartialBody tests that the body of a range request is returned
+// along with its position in the whole resource.
+func TestPartialBody(t *testing.T) {
+	tests := []struct {
+		name      string
+		resp      *http.Response
+		wantBody  string
+		wantStart int64
+		wantEnd   int64
+		wantTotal int64
+		wantErr   string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 416,
+				Body:       ioutil.NopCloser(strings.NewReader("nope")),
+			},
+			wantErr: "got status code 416 but wanted one of [200 206], body: nope",
+		},
+		{
+			name: "whole resource",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			wantBody:  "hello there",
+			wantStart: 0,
+			wantEnd:   10,
+			wantTotal: 11,
+		},
+		{
+			name: "partial content",
+			resp: &http.Response{
+				StatusCode: 206,
+				Header:     http.Header{"Content-Range": {"bytes 6-10/11"}},
+				Body:       ioutil.NopCloser(strings.NewReader("there")),
+			},
+			wantBody:  "there",
+			wantStart: 6,
+			wantEnd:   10,
+			wantTotal: 11,
+		},
+		{
+			name: "partial content with a malformed Content-Range",
+			resp: &http.Response{
+				StatusCode: 206,
+				Header:     http.Header{"Content-Range": {"bytes 6-/11"}},
+				Body:       ioutil.NopCloser(strings.NewReader("there")),
+			},
+			wantErr: `malformed Content-Range "bytes 6-/11": invalid end of range`,
+		},
+		{
+			name: "partial content shorter than its range",
+			resp: &http.Response{
+				StatusCode: 206,
+				Header:     http.Header{"Content-Range": {"bytes 6-10/11"}},
+				Body:       ioutil.NopCloser(strings.NewReader("the")),
+			},
+			wantErr: "got 3 bytes but the Content-Range header says there should be 5",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, start, end, total, err := httpparse.PartialBody(test.resp)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+			if start != test.wantStart || end != test.wantEnd || total != test.wantTotal {
+				t.Errorf("got range %d-%d/%d, wanted %d-%d/%d", start, end, total, test.wantStart, test.wantEnd, test.wantTotal)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errNoValue is returned by extractPath when there is no value at a
+// path.
+var errNoValue = errors.New("no value at path")
+
+// ExtractJSON pulls the values at the given paths out of a http
+// response who's body contains JSON, for when a couple of values deep
+// inside a big document are all you need and defining structs for all
+// of it is not worth it. The body is read just like RawBody reads it.
+// Paths are dotted, like "data.meta.version", and address array
+// elements by their index, like "items.0.id". A "#" stands for every
+// element of an array so "data.items.#.id" is the id of every item
+// (items without one are left out) and a path ending in "#" is the
+// length of the array. The values are keyed by their path and are what
+// unmarshalling into an interface{} gives you, except that numbers are
+// json.Number. It is an error for a path to lead nowhere.
+func ExtractJSON(resp *http.Response, wantStatus int, paths ...string) (map[string]interface{}, error) {
+	body, err := RawBody(resp, []int{wantStatus})
+	if err != nil {
+		return nil, err
+	}
+	if err := validJSON(body); err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{}, len(paths))
+	for _, path := range paths {
+		val, err := lookupValidPath(body, path)
+		if err != nil {
+			return nil, err
+		}
+		values[path] = val
+	}
+	return values, nil
+}
+
+// lookupPath returns the value at a dotted path (e.g.
+// "data.meta.version") in a JSON document, as described by ExtractJSON.
+func lookupPath(body []byte, path string) (interface{}, error) {
+	if err := validJSON(body); err != nil {
+		return nil, err
+	}
+	return lookupValidPath(body, path)
+}
+
+// validJSON returns an error unless body is valid JSON.
+func validJSON(body []byte) error {
+	if json.Valid(body) {
+		return nil
+	}
+	return &DecodeError{Err: json.Unmarshal(body, new(json.RawMessage))}
+}
+
+// lookupValidPath is lookupPath for a body known to be valid JSON.
+func lookupValidPath(body []byte, path string) (interface{}, error) {
+	val, err := extractPath(body, strings.Split(path, "."))
+	if err == errNoValue {
+		return nil, fmt.Errorf("response body has no value at path %q", path)
+	} else if err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+	return val, nil
+}
+
+// extractPath returns the value at the path made of keys in the valid
+// JSON doc. Only the parts of doc on the path get decoded.
+func extractPath(doc json.RawMessage, keys []string) (interface{}, error) {
+	if len(keys) == 0 {
+		dec := json.NewDecoder(bytes.NewReader(doc))
+		dec.UseNumber()
+		var val interface{}
+		err := dec.Decode(&val)
+		return val, err
+	}
+	key, rest := keys[0], keys[1:]
+	doc = bytes.TrimSpace(doc)
+	switch {
+	case len(doc) > 0 && doc[0] == '{':
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(doc, &obj); err != nil {
+			return nil, err
+		}
+		val, ok := obj[key]
+		if !ok {
+			return nil, errNoValue
+		}
+		return extractPath(val, rest)
+	case len(doc) > 0 && doc[0] == '[':
+		var arr []json.RawMessage
+		if err := json.Unmarshal(doc, &arr); err != nil {
+			return nil, err
+		}
+		if key == "#" {
+			if len(rest) == 0 {
+				return json.Number(strconv.Itoa(len(arr))), nil
+			}
+			vals := []interface{}{}
+			for _, elem := range arr {
+				val, err := extractPath(elem, rest)
+				if err == errNoValue {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				vals = append(vals, val)
+			}
+			return vals, nil
+		}
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(arr) {
+			return nil, errNoValue
+		}
+		return extractPath(arr[i], rest)
+	}
+	return nil, errNoValue
+}
@@ -0,0 +1,108 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestExtractJSON tests that values are pulled out of JSON bodies by
+// their path.
+func TestExtractJSON(t *testing.T) {
+	const doc = `{
+		"data": {
+			"items": [{"id": 1, "name": "a"}, {"name": "b"}, {"id": 3, "tags": ["x", "y"]}],
+			"meta": {"version": "v2", "empty": null}
+		}
+	}`
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		paths      []string
+		wantValues map[string]interface{}
+		wantErr    string
+	}{
+		{
+			name:       "unexpected response status code",
+			statusCode: 500,
+			body:       "woa there",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:       "invalid JSON",
+			statusCode: 200,
+			body:       `{"data": `,
+			paths:      []string{"data"},
+			wantErr:    "unmarshalling response body: unexpected end of JSON input",
+		},
+		{
+			name:       "values",
+			statusCode: 200,
+			body:       doc,
+			paths:      []string{"data.meta.version", "data.meta.empty", "data.items.2.tags", "data.items.0"},
+			wantValues: map[string]interface{}{
+				"data.meta.version": "v2",
+				"data.meta.empty":   nil,
+				"data.items.2.tags": []interface{}{"x", "y"},
+				"data.items.0":      map[string]interface{}{"id": json.Number("1"), "name": "a"},
+			},
+		},
+		{
+			name:       "every element",
+			statusCode: 200,
+			body:       doc,
+			paths:      []string{"data.items.#.id", "data.items.#.name", "data.items.#"},
+			wantValues: map[string]interface{}{
+				"data.items.#.id":   []interface{}{json.Number("1"), json.Number("3")},
+				"data.items.#.name": []interface{}{"a", "b"},
+				"data.items.#":      json.Number("3"),
+			},
+		},
+		{
+			name:       "missing key",
+			statusCode: 200,
+			body:       doc,
+			paths:      []string{"data.meta.version", "data.meta.nope"},
+			wantErr:    `response body has no value at path "data.meta.nope"`,
+		},
+		{
+			name:       "index out of range",
+			statusCode: 200,
+			body:       doc,
+			paths:      []string{"data.items.3"},
+			wantErr:    `response body has no value at path "data.items.3"`,
+		},
+		{
+			name:       "path through a scalar",
+			statusCode: 200,
+			body:       doc,
+			paths:      []string{"data.meta.version.major"},
+			wantErr:    `response body has no value at path "data.meta.version.major"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			values, err := httpparse.ExtractJSON(resp, 200, test.paths...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := values, test.wantValues; !reflect.DeepEqual(got, want) {
+				t.Errorf("got values %#v, wanted %#v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONPointer decodes the value at a JSON Pointer (RFC 6901), like
+// "/data/attributes/name", in a http response who's body contains JSON
+// into v, for when that one value is all you need. The body is read
+// just like RawBody reads it and the value is decoded like JSON decodes
+// a body, options included. The empty pointer "" is the whole body.
+// Within a pointer "~1" stands for a "/" in a key and "~0" for a "~".
+// It is an error for the pointer to lead nowhere.
+func JSONPointer(resp *http.Response, wantStatuses []int, pointer string, v interface{}, opts ...Option) error {
+	body, err := RawBody(resp, wantStatuses, opts...)
+	if err != nil {
+		return err
+	}
+	if err := validJSON(body); err != nil {
+		return err
+	}
+	raw, err := resolvePointer(body, pointer)
+	if err != nil {
+		return err
+	}
+	if err := decodeJSON(bytes.NewReader(raw), v, newConfig(opts)); err != nil {
+		return &DecodeError{Err: fmt.Errorf("value at JSON pointer %q: %v", pointer, err)}
+	}
+	return nil
+}
+
+// resolvePointer returns the value the JSON pointer points to in the
+// valid JSON doc.
+func resolvePointer(doc json.RawMessage, pointer string) (json.RawMessage, error) {
+	if pointer == "" {
+		return doc, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: it must be empty or start with a /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		// at is the part of the pointer which got us here, for
+		// the error messages.
+		at := "/" + strings.Join(tokens[:i+1], "/")
+		doc = bytes.TrimSpace(doc)
+		switch jsonKind(doc) {
+		case "an object":
+			var obj map[string]json.RawMessage
+			if err := json.Unmarshal(doc, &obj); err != nil {
+				return nil, &DecodeError{Err: err}
+			}
+			val, ok := obj[token]
+			if !ok {
+				return nil, fmt.Errorf("response body has no value at JSON pointer %q: there is no %q", pointer, at)
+			}
+			doc = val
+		case "an array":
+			var arr []json.RawMessage
+			if err := json.Unmarshal(doc, &arr); err != nil {
+				return nil, &DecodeError{Err: err}
+			}
+			n, err := strconv.Atoi(token)
+			if err != nil || !isArrayIndex(token) {
+				return nil, fmt.Errorf("response body has no value at JSON pointer %q: %q is not an array index", pointer, at)
+			}
+			if n >= len(arr) {
+				return nil, fmt.Errorf("response body has no value at JSON pointer %q: the array only has %d elements", pointer, len(arr))
+			}
+			doc = arr[n]
+		default:
+			parent := strings.Join(append([]string{""}, tokens[:i]...), "/")
+			return nil, fmt.Errorf("response body has no value at JSON pointer %q: %q is %s, not an object or array", pointer, parent, jsonKind(doc))
+		}
+	}
+	return doc, nil
+}
+
+// isArrayIndex reports whether token is an array index the way RFC 6901
+// spells them: digits without a sign or leading zeros.
+func isArrayIndex(token string) bool {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonKind describes the kind of value the valid JSON doc is.
+func jsonKind(doc json.RawMessage) string {
+	switch doc[0] {
+	case '{':
+		return "an object"
+	case '[':
+		return "an array"
+	case '"':
+		return "a string"
+	case 't', 'f':
+		return "a boolean"
+	case 'n':
+		return "null"
+	}
+	return "a number"
+}
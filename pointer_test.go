@@ -0,0 +1,189 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONPointer tests that the value at a JSON pointer is decoded.
+func TestJSONPointer(t *testing.T) {
+	const doc = `{
+		"data": {"attributes": {"name": "bob", "a/b": "slash", "m~n": "tilde"}},
+		"items": [{"value_one": "first"}, {"value_one": "second", "value_two": 2}]
+	}`
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		pointer    string
+		v          func() interface{}
+		want       string
+		wantErr    string
+	}{
+		{
+			name:       "unexpected response status code",
+			statusCode: 500,
+			body:       "woa there",
+			pointer:    "/data",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:       "string",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/attributes/name",
+			v:          func() interface{} { return new(string) },
+			want:       "bob",
+		},
+		{
+			name:       "escaped keys",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/attributes/a~1b",
+			v:          func() interface{} { return new(string) },
+			want:       "slash",
+		},
+		{
+			name:       "escaped tilde",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/attributes/m~0n",
+			v:          func() interface{} { return new(string) },
+			want:       "tilde",
+		},
+		{
+			name:       "array element into a struct",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/items/1",
+			v:          func() interface{} { return new(structuredJSON) },
+			want:       "{ValueOne:second ValueTwo:2}",
+		},
+		{
+			name:       "whole document",
+			statusCode: 200,
+			body:       `"just a string"`,
+			pointer:    "",
+			v:          func() interface{} { return new(string) },
+			want:       "just a string",
+		},
+		{
+			name:       "missing key",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/relationships/owner",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/data/relationships/owner": there is no "/data/relationships"`,
+		},
+		{
+			name:       "index out of range",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/items/2",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/items/2": the array only has 2 elements`,
+		},
+		{
+			name:       "not an index",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/items/01",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/items/01": "/items/01" is not an array index`,
+		},
+		{
+			name:       "index with a sign",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/items/+1",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/items/+1": "/items/+1" is not an array index`,
+		},
+		{
+			name:       "negative zero index",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/items/-0",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/items/-0": "/items/-0" is not an array index`,
+		},
+		{
+			name:       "missing key at the root",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/nope",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/nope": there is no "/nope"`,
+		},
+		{
+			name:       "inside of a scalar at the root",
+			statusCode: 200,
+			body:       `"just a string"`,
+			pointer:    "/name",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/name": "" is a string, not an object or array`,
+		},
+		{
+			name:       "inside of a scalar",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/attributes/name/first",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `response body has no value at JSON pointer "/data/attributes/name/first": "/data/attributes/name" is a string, not an object or array`,
+		},
+		{
+			name:       "wrong type",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "/data/attributes",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `unmarshalling response body: value at JSON pointer "/data/attributes": json: cannot unmarshal object into Go value of type string`,
+		},
+		{
+			name:       "invalid pointer",
+			statusCode: 200,
+			body:       doc,
+			pointer:    "data",
+			v:          func() interface{} { return new(string) },
+			wantErr:    `invalid JSON pointer "data": it must be empty or start with a /`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var v interface{} = new(string)
+			if test.v != nil {
+				v = test.v()
+			}
+			err := httpparse.JSONPointer(resp, []int{200}, test.pointer, v)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if test.wantErr != "" {
+				return
+			}
+			var got string
+			switch v := v.(type) {
+			case *string:
+				got = *v
+			default:
+				got = fmt.Sprintf("%+v", v)
+				got = strings.TrimPrefix(got, "&")
+			}
+			if want := test.want; got != want {
+				t.Errorf("got %s, wanted %s", got, want)
+			}
+		})
+	}
+}
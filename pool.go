@@ -0,0 +1,85 @@
+package httpparse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxPooledSize is the capacity above which a buffer does not go back
+// into the pool, so that the odd huge body does not stay in memory for
+// the life of the process.
+const maxPooledSize = 1 << 20
+
+var (
+	bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	readerPool = sync.Pool{New: func() interface{} { return bufio.NewReader(nil) }}
+)
+
+// Buffer holds a response body read by RawBodyPooled. Its memory is
+// reused for another body once it is released.
+type Buffer struct {
+	buf *bytes.Buffer
+}
+
+// Bytes returns the body. It must not be used after the Buffer is
+// released, copy it if you need it for longer.
+func (b *Buffer) Bytes() []byte {
+	if b.buf == nil {
+		return nil
+	}
+	return b.buf.Bytes()
+}
+
+// Release hands the Buffer's memory back for reuse. Releasing a Buffer
+// more than once does nothing.
+func (b *Buffer) Release() {
+	if b.buf == nil {
+		return
+	}
+	putBuffer(b.buf)
+	b.buf = nil
+}
+
+// RawBodyPooled is RawBody for services which read lots of bodies and
+// would rather not allocate a new slice for every one of them. The body
+// is read into a Buffer which comes from a pool and goes back into it
+// when released. Only bodies of up to a megabyte are pooled, bigger
+// ones are left to the garbage collector. RawBody, which hands out a
+// body you can keep, is the safer choice unless allocations are a
+// measured problem.
+func RawBodyPooled(resp *http.Response, wantStatuses []int, opts ...Option) (*Buffer, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	opts = append(opts[:len(opts):len(opts)], func(c *config) { c.pooled = buf })
+	// On error buf is not put back since the error may hold on to
+	// the body.
+	if _, err := RawBody(resp, wantStatuses, opts...); err != nil {
+		return nil, err
+	}
+	return &Buffer{buf: buf}, nil
+}
+
+// putBuffer puts buf back into the pool, unless it has grown too big.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// getReader returns a pooled bufio.Reader reading from r. Put it back
+// with putReader once nothing uses it anymore.
+func getReader(r io.Reader) *bufio.Reader {
+	br := readerPool.Get().(*bufio.Reader)
+	br.Reset(r)
+	return br
+}
+
+// putReader puts br back into the pool.
+func putReader(br *bufio.Reader) {
+	br.Reset(nil)
+	readerPool.Put(br)
+}
@@ -0,0 +1,113 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRawBodyPooled tests that pooled bodies are read like RawBody
+// reads them.
+func TestRawBodyPooled(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		opts       []httpparse.Option
+		wantBody   string
+		wantErr    string
+	}{
+		{
+			name:       "unexpected status code",
+			statusCode: 500,
+			body:       "woa there",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:       "body",
+			statusCode: 200,
+			body:       "hello there",
+			wantBody:   "hello there",
+		},
+		{
+			name:       "over the limit",
+			statusCode: 200,
+			body:       "hello there",
+			opts:       []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr:    "The response body contained more than the limit of 5 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Going around a few times makes sure a reused buffer
+			// starts out empty.
+			for i := 0; i < 3; i++ {
+				resp := &http.Response{
+					StatusCode: test.statusCode,
+					Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+				}
+				buf, err := httpparse.RawBodyPooled(resp, []int{200}, test.opts...)
+
+				if test.wantErr == "" && err != nil {
+					t.Fatalf("got a non-nil error: %v", err)
+				} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" {
+					if !strings.Contains(got, want) {
+						t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+					}
+					return
+				}
+				if got, want := string(buf.Bytes()), test.wantBody; got != want {
+					t.Errorf("got body %q, wanted %q", got, want)
+				}
+				buf.Release()
+				buf.Release()
+				if got := buf.Bytes(); got != nil {
+					t.Errorf("got body %q after releasing the buffer, wanted nil", got)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRawBody compares reading bodies into fresh slices with
+// reading them into pooled buffers.
+func BenchmarkRawBody(b *testing.B) {
+	body := strings.Repeat(`{"value_one":"hello there","value_two":42}`, 1000)
+	b.Run("fresh", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}
+			if _, err := httpparse.RawBody(resp, []int{200}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}
+			buf, err := httpparse.RawBodyPooled(resp, []int{200})
+			if err != nil {
+				b.Fatal(err)
+			}
+			buf.Release()
+		}
+	})
+}
+
+// BenchmarkJSONSmall shows the allocations made decoding a small body,
+// which is what most API responses are.
+func BenchmarkJSONSmall(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resp := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{"value_one":"hello there","value_two":42}`))}
+		var data structuredJSON
+		if err := httpparse.JSON(resp, []int{200}, &data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
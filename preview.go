@@ -0,0 +1,51 @@
+package httpparse
+
+import "unicode/utf8"
+
+// defaultPreviewSize is how much of a body error messages show unless
+// WithPreviewSize says otherwise.
+const defaultPreviewSize = 512
+
+// previewBody returns the beginning of body, no more than n bytes of
+// it, for showing in an error message. It is cut at a UTF-8 boundary so
+// a multi-byte character never gets sliced in half. cut reports
+// whether anything was left off.
+func previewBody(body []byte, n int) (preview []byte, cut bool) {
+	if len(body) <= n {
+		return body, false
+	}
+	body = body[:n]
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				body = body[:i]
+			}
+			break
+		}
+	}
+	return body, true
+}
+
+// ellipsis marks a preview of which something was left off.
+func ellipsis(cut bool) string {
+	if cut {
+		return "..."
+	}
+	return ""
+}
+
+// prefixWriter keeps the first max bytes written to it.
+type prefixWriter struct {
+	buf []byte
+	max int
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	if room := p.max - len(p.buf); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		p.buf = append(p.buf, b[:room]...)
+	}
+	return len(b), nil
+}
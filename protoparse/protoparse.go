@@ -0,0 +1,45 @@
+// Package protoparse parses http responses who's body contains a
+// protocol buffer message. It lives apart from httpparse so that only
+// those who need protobuf depend on it.
+package protoparse
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/lag13/httpparse"
+	"google.golang.org/protobuf/proto"
+)
+
+// ContentType is the media type a response must have for Proto to
+// parse it, unless overridden with httpparse.WithContentType.
+const ContentType = "application/x-protobuf"
+
+// Proto parses a http response who's body contains a binary encoded
+// protocol buffer message into msg and closes the response body. The
+// response's Content-Type must be application/x-protobuf, which makes
+// for a much clearer error when a server responds with an HTML error
+// page than whatever the protobuf parser would make of it. Otherwise
+// it works just like httpparse.JSON does, including the status code
+// checks, read limit, error messages and options.
+func Proto(resp *http.Response, wantStatus int, msg proto.Message, opts ...httpparse.Option) error {
+	opts = append([]httpparse.Option{httpparse.WithContentType(ContentType)}, opts...)
+	opts = append(opts, httpparse.WithExpectedStatuses(wantStatus), httpparse.WithDecoder(decode))
+	return httpparse.NewParser(opts...).Decode(resp, msg)
+}
+
+func decode(r io.Reader, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return errors.New("not a proto.Message")
+	}
+	// The wire format is not self delimiting so there is nothing to
+	// do but read it all.
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(body, msg)
+}
@@ -0,0 +1,101 @@
+package protoparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+	"github.com/lag13/httpparse/protoparse"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func marshal(msg proto.Message) string {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// TestProto tests that parsing a http response with a protobuf body
+// returns an error when expected and unmarshals the message otherwise.
+func TestProto(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCode  int
+		contentType string
+		body        string
+		opts        []httpparse.Option
+		wantValue   string
+		wantErr     string
+	}{
+		{
+			name:        "unexpected response status code",
+			statusCode:  500,
+			contentType: "text/plain",
+			body:        "woa there",
+			wantErr:     "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:        "wrong Content-Type",
+			statusCode:  200,
+			contentType: "text/html",
+			body:        "<html>oops</html>",
+			wantErr:     "expected Content-Type application/x-protobuf but got text/html; body: <html>oops</html>",
+		},
+		{
+			name:        "Content-Type overridden",
+			statusCode:  200,
+			contentType: "application/protobuf",
+			body:        marshal(wrapperspb.String("hello there")),
+			opts:        []httpparse.Option{httpparse.WithContentType("application/protobuf")},
+			wantValue:   "hello there",
+		},
+		{
+			name:        "error when unmarshalling response body",
+			statusCode:  200,
+			contentType: "application/x-protobuf",
+			body:        "\x0a\x05hi",
+			wantErr:     "unmarshalling response body: proto:",
+		},
+		{
+			name:        "response body exceeded the limit",
+			statusCode:  200,
+			contentType: "application/x-protobuf",
+			body:        marshal(wrapperspb.String("hello there")),
+			opts:        []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr:     "The response body contained more than the limit of 5 bytes",
+		},
+		{
+			name:        "got the message",
+			statusCode:  200,
+			contentType: "application/x-protobuf",
+			body:        marshal(wrapperspb.String("hello there")),
+			wantValue:   "hello there",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var msg wrapperspb.StringValue
+			err := protoparse.Proto(resp, 200, &msg, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := msg.GetValue(), test.wantValue; got != want {
+				t.Errorf("got value %q, wanted %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package httpparse
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is what a response's headers say about the client's rate
+// limit.
+type RateLimit struct {
+	// Limit is how many requests are allowed in the current window,
+	// -1 if the headers do not say.
+	Limit int
+	// Remaining is how many requests are left in the current window,
+	// -1 if the headers do not say.
+	Remaining int
+	// Reset is when the current window ends, the zero time if the
+	// headers do not say.
+	Reset time.Time
+}
+
+// ParseRateLimit returns what the response's rate limit headers say:
+// either the common X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset or the IETF draft's RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset. The X- headers win when a
+// response has both. A reset is understood as a Unix timestamp when it
+// is large enough to be one, like GitHub sends, and otherwise as the
+// number of seconds left, like the draft says. Since it only looks at
+// the headers it works just as well on a response with an error status
+// code. Headers which are absent are -1 (or the zero time) in the
+// result, headers which are malformed are an error.
+func ParseRateLimit(resp *http.Response) (RateLimit, error) {
+	var rl RateLimit
+	var err error
+	if rl.Limit, err = rateLimitHeader(resp.Header, "Limit"); err != nil {
+		return RateLimit{}, err
+	}
+	if rl.Remaining, err = rateLimitHeader(resp.Header, "Remaining"); err != nil {
+		return RateLimit{}, err
+	}
+	reset, err := rateLimitHeader(resp.Header, "Reset")
+	if err != nil {
+		return RateLimit{}, err
+	}
+	// Any timestamp after September 2001 is bigger than this, any
+	// sensible number of seconds to wait is smaller.
+	if reset >= 1e9 {
+		rl.Reset = time.Unix(int64(reset), 0)
+	} else if reset >= 0 {
+		rl.Reset = time.Now().Add(time.Duration(reset) * time.Second)
+	}
+	return rl, nil
+}
+
+// rateLimitHeader returns the value of the X-RateLimit-<name> or
+// RateLimit-<name> header, -1 if there is neither. Only the first number
+// of a list, like the draft's "100, 100;w=60", counts.
+func rateLimitHeader(h http.Header, name string) (int, error) {
+	for _, header := range []string{"X-RateLimit-" + name, "RateLimit-" + name} {
+		val := strings.TrimSpace(h.Get(header))
+		if val == "" {
+			continue
+		}
+		if i := strings.IndexAny(val, ",;"); i >= 0 {
+			val = strings.TrimSpace(val[:i])
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("parsing %s header: %v", header, err)
+		}
+		return n, nil
+	}
+	return -1, nil
+}
+
+// CheckRateLimit returns an error if the response's rate limit headers
+// (X-RateLimit-Remaining or RateLimit-Remaining) say that fewer than
+// minRemaining requests are left, letting a client slow itself down
+// before it starts getting 429s. When the headers are absent the
+// budget is unknown and nil is returned.
+func CheckRateLimit(resp *http.Response, minRemaining int) error {
+	remaining, err := rateLimitHeader(resp.Header, "Remaining")
+	if err != nil {
+		return err
+	}
+	if remaining >= 0 && remaining < minRemaining {
+		return fmt.Errorf("rate limit nearly exhausted: %d requests remaining, below threshold %d", remaining, minRemaining)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestCheckRateLimit tests that an error is returned when the rate
+// limit budget drops below the threshold.
+func TestCheckRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		wantErr string
+	}{
+		{
+			name:    "no rate limit headers",
+			header:  nil,
+			wantErr: "",
+		},
+		{
+			name:    "malformed header",
+			header:  http.Header{"X-Ratelimit-Remaining": {"lots"}},
+			wantErr: `parsing X-RateLimit-Remaining header: strconv.Atoi: parsing "lots"`,
+		},
+		{
+			name:    "plenty remaining",
+			header:  http.Header{"X-Ratelimit-Remaining": {"10"}},
+			wantErr: "",
+		},
+		{
+			name:    "below the threshold",
+			header:  http.Header{"X-Ratelimit-Remaining": {"9"}},
+			wantErr: "rate limit nearly exhausted: 9 requests remaining, below threshold 10",
+		},
+		{
+			name:    "below the threshold according to the IETF header",
+			header:  http.Header{"Ratelimit-Remaining": {"0"}},
+			wantErr: "rate limit nearly exhausted: 0 requests remaining, below threshold 10",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := httpparse.CheckRateLimit(&http.Response{Header: test.header}, 10)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+		})
+	}
+}
+
+// TestParseRateLimit tests that the rate limit headers are parsed.
+func TestParseRateLimit(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		header    http.Header
+		wantLimit int
+		wantLeft  int
+		wantReset time.Time
+		wantErr   string
+	}{
+		{
+			name:      "no rate limit headers",
+			header:    nil,
+			wantLimit: -1,
+			wantLeft:  -1,
+		},
+		{
+			name: "X- headers with a timestamp",
+			header: http.Header{
+				"X-Ratelimit-Limit":     {"5000"},
+				"X-Ratelimit-Remaining": {"4999"},
+				"X-Ratelimit-Reset":     {"1700000000"},
+			},
+			wantLimit: 5000,
+			wantLeft:  4999,
+			wantReset: time.Unix(1700000000, 0),
+		},
+		{
+			name: "IETF headers with seconds",
+			header: http.Header{
+				"Ratelimit-Limit":     {"100, 100;w=60"},
+				"Ratelimit-Remaining": {"50"},
+				"Ratelimit-Reset":     {"30"},
+			},
+			wantLimit: 100,
+			wantLeft:  50,
+			wantReset: now.Add(30 * time.Second),
+		},
+		{
+			name: "X- headers win",
+			header: http.Header{
+				"X-Ratelimit-Remaining": {"1"},
+				"Ratelimit-Remaining":   {"2"},
+			},
+			wantLimit: -1,
+			wantLeft:  1,
+		},
+		{
+			name:    "malformed header",
+			header:  http.Header{"Ratelimit-Reset": {"soon"}},
+			wantErr: `parsing RateLimit-Reset header: strconv.Atoi: parsing "soon"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rl, err := httpparse.ParseRateLimit(&http.Response{StatusCode: 429, Header: test.header})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if test.wantErr != "" {
+				return
+			}
+			if rl.Limit != test.wantLimit || rl.Remaining != test.wantLeft {
+				t.Errorf("got limit %d and remaining %d, wanted %d and %d", rl.Limit, rl.Remaining, test.wantLimit, test.wantLeft)
+			}
+			if diff := rl.Reset.Sub(test.wantReset); diff < -time.Second || diff > time.Second || rl.Reset.IsZero() != test.wantReset.IsZero() {
+				t.Errorf("got reset %v, wanted %v", rl.Reset, test.wantReset)
+			}
+		})
+	}
+}
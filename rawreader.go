@@ -0,0 +1,64 @@
+package httpparse
+
+import (
+	"io"
+	"net/http"
+)
+
+// RawReader checks the status code of a http response like RawBody
+// does but, instead of reading the body, returns a reader for it so
+// that large bodies can be streamed to disk or elsewhere without
+// holding them in memory. Only when the status code is not one of
+// wantStatuses is (the beginning of) the body read, for the error. The
+// reader decompresses the body like RawBody does and, once more than
+// limit bytes have been read from it, errors with ErrBodyTooLarge
+// instead of returning the rest. Closing it closes the response body,
+// which the caller must do.
+func RawReader(resp *http.Response, wantStatuses []int, limit int64, opts ...Option) (io.ReadCloser, error) {
+	c := newConfig(append(opts[:len(opts):len(opts)], WithReadLimit(limit)))
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if got, wants := resp.StatusCode, wantStatuses; !contains(wants, got) && !c.acceptsStatus(got) {
+		defer drainAndClose(resp.Body, c.readLimit)
+		return nil, mismatchError(resp, r, c, wants)
+	}
+	return &limitedBody{
+		lr:   &io.LimitedReader{R: r, N: limit + 1},
+		c:    c,
+		body: resp.Body,
+	}, nil
+}
+
+// limitedBody reads a response body, erroring once it has read more
+// than one of the limits allow.
+type limitedBody struct {
+	lr   *io.LimitedReader
+	c    *config
+	body io.Closer
+	err  error
+}
+
+func (l *limitedBody) Read(b []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	n, err := l.lr.Read(b)
+	if l.lr.N <= 0 {
+		// The byte past the limit is not part of what we return.
+		n--
+	}
+	if err != nil || l.lr.N <= 0 {
+		if limitErr := l.c.overLimit(l.lr); limitErr != nil {
+			err = limitErr
+		}
+		l.err = err
+	}
+	return n, err
+}
+
+func (l *limitedBody) Close() error {
+	return l.body.Close()
+}
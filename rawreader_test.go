@@ -0,0 +1,118 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRawReader tests that the returned reader streams the body, up to
+// the limit, once the status code checks out.
+func TestRawReader(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusCode      int
+		contentEncoding string
+		body            string
+		limit           int64
+		opts            []httpparse.Option
+		wantBody        string
+		wantErr         string
+		wantReadErr     string
+		wantTooLarge    bool
+	}{
+		{
+			name:       "unexpected status code",
+			statusCode: 500,
+			body:       "woa there",
+			limit:      100,
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:       "within the limit",
+			statusCode: 200,
+			body:       "hello there",
+			limit:      100,
+			wantBody:   "hello there",
+		},
+		{
+			name:       "exactly the limit",
+			statusCode: 200,
+			body:       "hello there",
+			limit:      11,
+			wantBody:   "hello there",
+		},
+		{
+			name:         "over the limit",
+			statusCode:   200,
+			body:         "hello there",
+			limit:        5,
+			wantBody:     "hello",
+			wantReadErr:  "The response body contained more than the limit of 5 bytes",
+			wantTooLarge: true,
+		},
+		{
+			name:            "decompressed",
+			statusCode:      200,
+			contentEncoding: "gzip",
+			body:            gzipped("hello there"),
+			limit:           100,
+			wantBody:        "hello there",
+		},
+		{
+			name:            "decompressed over the limit",
+			statusCode:      200,
+			contentEncoding: "gzip",
+			body:            gzipped(strings.Repeat("a", 1000)),
+			limit:           100,
+			wantBody:        strings.Repeat("a", 100),
+			wantReadErr:     "The response body decompressed into more than the limit of 100 bytes",
+			wantTooLarge:    true,
+		},
+		{
+			name:       "status matcher",
+			statusCode: 201,
+			body:       "hello there",
+			limit:      100,
+			opts:       []httpparse.Option{httpparse.WithStatusMatcher(httpparse.Status2xx)},
+			wantBody:   "hello there",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			r, err := httpparse.RawReader(resp, []int{200}, test.limit, test.opts...)
+			if test.wantErr == "" && err != nil {
+				t.Fatalf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" {
+				if !strings.Contains(got, want) {
+					t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+				}
+				return
+			}
+			defer r.Close()
+
+			body, err := ioutil.ReadAll(r)
+			if test.wantReadErr == "" && err != nil {
+				t.Errorf("got a non-nil read error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantReadErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got read error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := errors.Is(err, httpparse.ErrBodyTooLarge), test.wantTooLarge; got != want {
+				t.Errorf("got ErrBodyTooLarge %t, wanted %t", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
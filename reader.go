@@ -0,0 +1,108 @@
+package httpparse
+
+import (
+	"io"
+	"io/ioutil"
+)
+
+// ReadAll reads r to the end just like RawBody reads a response body,
+// read limit and error messages included, for when the body did not
+// come from a *http.Response (like one replayed from a cache).
+func ReadAll(r io.Reader, opts ...Option) ([]byte, error) {
+	return readAll(r, newConfig(opts))
+}
+
+// DecodeJSONReader decodes the JSON in r into v just like JSON decodes
+// a response body, read limit and error messages included, for when
+// the body did not come from a *http.Response (like one replayed from a
+// cache). The options which are about the response itself, like
+// WithContentType, have no effect.
+func DecodeJSONReader(r io.Reader, v interface{}, opts ...Option) error {
+	return decodeLimited(r, newConfig(opts), func(r io.Reader, c *config) error {
+		return decodeJSON(r, v, c)
+	})
+}
+
+// readAll reads all of r but errors when there is more of it than the
+// read limit allows.
+func readAll(r io.Reader, c *config) ([]byte, error) {
+	limitedReader := &io.LimitedReader{
+		R: r,
+		N: c.readLimit + 1,
+	}
+	var body []byte
+	var err error
+	if c.pooled != nil {
+		_, err = c.pooled.ReadFrom(limitedReader)
+		body = c.pooled.Bytes()
+	} else {
+		body, err = ioutil.ReadAll(limitedReader)
+	}
+	// Running out of compressed bytes makes the decompression fail but
+	// the limit is the real problem.
+	if err := c.overLimit(limitedReader); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &ReadError{Err: err}
+	}
+	return body, nil
+}
+
+// overLimit returns the error for a body that was read through
+// limitedReader having been bigger than one of the limits, nil if it
+// was not.
+func (c *config) overLimit(limitedReader *io.LimitedReader) error {
+	if c.compressed != nil && c.compressed.exceeded() {
+		return tooLargeError{maxBytes: c.zipLimit, compressed: true}
+	}
+	if limitedReader.N <= 0 {
+		return tooLargeError{maxBytes: c.readLimit, decompressed: c.compressed != nil}
+	}
+	return nil
+}
+
+// decodeLimited calls decode on r but errors when decode reads more of
+// it than the read limit allows.
+func decodeLimited(r io.Reader, c *config, decode func(r io.Reader, c *config) error) error {
+	limitedReader := &io.LimitedReader{
+		R: r,
+		N: c.readLimit + 1,
+	}
+	// The errors from decoders only show a tiny bit of the body, if
+	// any, which is not enough to figure out what the server actually
+	// sent (often an HTML error page) so we hang on to the beginning
+	// of it.
+	preview := &prefixWriter{max: c.previewSize + 1}
+	body := io.TeeReader(limitedReader, preview)
+	if c.tee != nil {
+		body = io.TeeReader(body, c.tee)
+	}
+	err := decode(body, c)
+	// The decoder need not read all of the body but whoever wants a
+	// copy of it wants all of it.
+	if err == nil && c.tee != nil {
+		if _, err := io.Copy(ioutil.Discard, body); err != nil && c.overLimit(limitedReader) == nil {
+			return &ReadError{Err: err}
+		}
+	}
+	// Running out of bytes will probably make the decoding fail but
+	// the limit is the real problem.
+	if err := c.overLimit(limitedReader); err != nil {
+		return err
+	}
+	// Same goes for the context being done.
+	if err != nil && c.ctx != nil && c.ctx.Err() != nil {
+		return &ReadError{Err: c.ctx.Err()}
+	}
+	// An error the server put in an envelope is not a problem with
+	// the body.
+	if envErr, ok := err.(*EnvelopeError); ok {
+		return envErr
+	}
+	if err != nil {
+		body, cut := previewBody(preview.buf, c.previewSize)
+		return &DecodeError{Err: err, Body: append([]byte{}, body...), Truncated: cut}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestReadAll tests that readers are read just like response bodies.
+func TestReadAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        io.Reader
+		opts     []httpparse.Option
+		wantBody string
+		wantErr  string
+	}{
+		{
+			name:    "error reading",
+			r:       errReadCloser{readErr: errors.New("some read err")},
+			wantErr: "reading response body: some read err",
+		},
+		{
+			name:    "exceeded the limit",
+			r:       strings.NewReader("hello there buddy"),
+			opts:    []httpparse.Option{httpparse.WithReadLimit(5)},
+			wantErr: "ioutil.ReadAll() is used to read the response body and we limit how much it can read because nothing is infinite. The response body contained more than the limit of 5 bytes. Either increase the limit or parse the response body another way",
+		},
+		{
+			name:     "read everything",
+			r:        strings.NewReader("hello there buddy"),
+			wantBody: "hello there buddy",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, err := httpparse.ReadAll(test.r, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestDecodeJSONReader tests that JSON is decoded from readers just
+// like it is from response bodies.
+func TestDecodeJSONReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		r        io.Reader
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:    "invalid JSON",
+			r:       strings.NewReader("lats"),
+			wantErr: "unmarshalling response body: invalid character 'l'",
+		},
+		{
+			name:    "exceeded the limit",
+			r:       strings.NewReader(`{"value_one":"hello there"}`),
+			opts:    []httpparse.Option{httpparse.WithReadLimit(10)},
+			wantErr: "The response body contained more than the limit of 10 bytes",
+		},
+		{
+			name: "options are applied",
+			r:    strings.NewReader(`{"value_one":"hi"}`),
+			opts: []httpparse.Option{httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) {
+				return json.RawMessage(strings.ToUpper(string(value))), nil
+			})},
+			wantData: structuredJSON{ValueOne: "HI"},
+		},
+		{
+			name:     "decoded",
+			r:        strings.NewReader(`{"value_one":"hello there","value_two":42}`),
+			wantData: structuredJSON{ValueOne: "hello there", ValueTwo: 42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.DecodeJSONReader(test.r, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
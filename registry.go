@@ -0,0 +1,47 @@
+package httpparse
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]DecodeFunc{}
+)
+
+// RegisterDecoder teaches DecodeByContentType to decode bodies of the
+// given media type, e.g. "application/msgpack", with fn. A registered
+// decoder takes precedence over the built in ones so it can also be used
+// to replace those. Registering a nil fn removes the decoder again. It
+// is safe to call from multiple goroutines but is meant to be called
+// once, when the program starts:
+//
+//	func init() {
+//		httpparse.RegisterDecoder("application/msgpack", msgpackparse.Decode)
+//	}
+func RegisterDecoder(mediaType string, fn DecodeFunc) {
+	mediaType = strings.ToLower(mediaType)
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	if fn == nil {
+		delete(decoders, mediaType)
+		return
+	}
+	decoders[mediaType] = fn
+}
+
+// registeredDecoder returns the decoder registered for mediaType, nil
+// if there is none.
+func registeredDecoder(mediaType string) func(r io.Reader, v interface{}, c *config) error {
+	decodersMu.RLock()
+	fn := decoders[mediaType]
+	decodersMu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return func(r io.Reader, v interface{}, c *config) error {
+		return fn(r, v)
+	}
+}
@@ -0,0 +1,79 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRegisterDecoder tests that registered decoders are used by
+// DecodeByContentType.
+func TestRegisterDecoder(t *testing.T) {
+	// A made up format which is JSON with the keys upper cased.
+	httpparse.RegisterDecoder("Application/X-Shouty", func(r io.Reader, v interface{}) error {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal([]byte(strings.ToLower(string(b))), v)
+	})
+	defer httpparse.RegisterDecoder("application/x-shouty", nil)
+	// Registered decoders take precedence over the built in ones.
+	httpparse.RegisterDecoder("text/plain", func(r io.Reader, v interface{}) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+	defer httpparse.RegisterDecoder("text/plain", nil)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantData    structuredJSON
+		wantErr     string
+	}{
+		{
+			name:        "custom media type",
+			contentType: "application/x-shouty; charset=utf-8",
+			body:        `{"VALUE_ONE":"HI","VALUE_TWO":42}`,
+			wantData:    structuredJSON{ValueOne: "hi", ValueTwo: 42},
+		},
+		{
+			name:        "replaced media type",
+			contentType: "text/plain",
+			body:        `{"value_two":42}`,
+			wantData:    structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:        "decoder error",
+			contentType: "application/x-shouty",
+			body:        `<NOPE>`,
+			wantErr:     "unmarshalling response body: invalid character '<'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {test.contentType}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.DecodeByContentType(resp, []int{200}, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package httpparse
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RequireSafeMethod returns an error unless the request which produced
+// resp used a safe method (GET, HEAD or OPTIONS). Caching and retry
+// layers can use it to guard code paths which assume that repeating
+// the request has no side effects.
+func RequireSafeMethod(resp *http.Response) error {
+	if resp.Request == nil {
+		return errors.New("response has no request so its method cannot be checked")
+	}
+	switch method := resp.Request.Method; method {
+	// An empty method means GET for a client request.
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	default:
+		return fmt.Errorf("request method %s is not safe, wanted one of GET, HEAD or OPTIONS", method)
+	}
+}
+
+// RequireNotDeprecated returns an error if the response carries a
+// Deprecation header (draft-ietf-httpapi-deprecation-header) saying
+// the resource is deprecated. Running it in a test suite is a cheap
+// way to find out about upstream deprecations before they become
+// outages. The header's value can be "true", a date, or (in early
+// drafts) "false" which, along with the header being absent, means the
+// resource is not deprecated. The Sunset header (RFC 8594), which says
+// when the resource will go away, is included in the error if present.
+func RequireNotDeprecated(resp *http.Response) error {
+	deprecation := strings.TrimSpace(resp.Header.Get("Deprecation"))
+	if deprecation == "" || strings.EqualFold(deprecation, "false") {
+		return nil
+	}
+	msg := fmt.Sprintf("response is deprecated (Deprecation: %s)", deprecation)
+	if sunset := resp.Header.Get("Sunset"); sunset != "" {
+		msg += fmt.Sprintf(" and will be removed at %s", sunset)
+	}
+	return errors.New(msg)
+}
@@ -0,0 +1,107 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRequireSafeMethod tests that only responses to safe requests
+// are accepted.
+func TestRequireSafeMethod(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *http.Response
+		wantErr string
+	}{
+		{
+			name:    "no request",
+			resp:    &http.Response{},
+			wantErr: "response has no request so its method cannot be checked",
+		},
+		{
+			name:    "empty method means GET",
+			resp:    &http.Response{Request: &http.Request{}},
+			wantErr: "",
+		},
+		{
+			name:    "GET",
+			resp:    &http.Response{Request: &http.Request{Method: "GET"}},
+			wantErr: "",
+		},
+		{
+			name:    "HEAD",
+			resp:    &http.Response{Request: &http.Request{Method: "HEAD"}},
+			wantErr: "",
+		},
+		{
+			name:    "OPTIONS",
+			resp:    &http.Response{Request: &http.Request{Method: "OPTIONS"}},
+			wantErr: "",
+		},
+		{
+			name:    "POST",
+			resp:    &http.Response{Request: &http.Request{Method: "POST"}},
+			wantErr: "request method POST is not safe, wanted one of GET, HEAD or OPTIONS",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := httpparse.RequireSafeMethod(test.resp)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+		})
+	}
+}
+
+// TestRequireNotDeprecated tests that responses flagged as deprecated
+// are reported.
+func TestRequireNotDeprecated(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  http.Header
+		wantErr string
+	}{
+		{
+			name:    "no Deprecation header",
+			header:  nil,
+			wantErr: "",
+		},
+		{
+			name:    "not deprecated",
+			header:  http.Header{"Deprecation": {"False"}},
+			wantErr: "",
+		},
+		{
+			name:    "deprecated",
+			header:  http.Header{"Deprecation": {"true"}},
+			wantErr: "response is deprecated (Deprecation: true)",
+		},
+		{
+			name: "deprecated with a sunset date",
+			header: http.Header{
+				"Deprecation": {"@1688169599"},
+				"Sunset":      {"Sun, 30 Jun 2024 23:59:59 GMT"},
+			},
+			wantErr: "response is deprecated (Deprecation: @1688169599) and will be removed at Sun, 30 Jun 2024 23:59:59 GMT",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := httpparse.RequireNotDeprecated(&http.Response{Header: test.header})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package httpparse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryBackoff is how long we wait before the first retry when the
+// response does not say how long to wait. Every retry after that waits
+// this much longer.
+const retryBackoff = 100 * time.Millisecond
+
+// maxRetryBackoff is the longest DoWithRetry waits between attempts
+// unless WithRetryBackoff says otherwise.
+const maxRetryBackoff = 10 * time.Second
+
+// defaultRetryAttempts and defaultRetryOn are how DoWithRetry retries
+// without WithRetry.
+var (
+	defaultRetryAttempts = 3
+	defaultRetryOn       = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+)
+
+// Retry calls do, which should perform a request, until the response's
+// status code is not one of those given to WithRetry or the attempts
+// run out, and returns that last response. Since do gets called again
+// it must be able to re-issue the request, which means recreating any
+// request body. The bodies of the responses which get retried are
+// drained and closed. Parse the response it returns with the same
+// options as usual:
+//
+//	opts := []httpparse.Option{httpparse.WithRetry(3, []int{429, 502, 503})}
+//	resp, err := httpparse.Retry(func() (*http.Response, error) {
+//		return client.Do(newRequest())
+//	}, opts...)
+//	if err != nil {
+//		return err
+//	}
+//	err = httpparse.JSON(resp, []int{200}, &v, opts...)
+//
+// When the attempts run out the last response, with its retryable
+// status code, gets returned so parsing it gives you the usual
+// *StatusError. A Retry-After header, in seconds or as a date, says
+// how long to wait before retrying, otherwise the wait grows by 100ms
+// with every attempt. A response whose Retry-After asks for a longer
+// wait than WithRetryBackoff allows, 10s by default, is returned
+// without waiting since retrying any sooner would most likely fail
+// again. An error from do is returned as "performing request: ..."
+// without retrying.
+func Retry(do func() (*http.Response, error), opts ...Option) (*http.Response, error) {
+	return RetryContext(context.Background(), do, opts...)
+}
+
+// RetryContext is Retry but it stops waiting to retry once ctx is done,
+// returning an error like "performing request: context canceled".
+func RetryContext(ctx context.Context, do func() (*http.Response, error), opts ...Option) (*http.Response, error) {
+	c := newConfig(opts)
+	for attempt := 1; ; attempt++ {
+		resp, err := do()
+		if err != nil {
+			return nil, fmt.Errorf("performing request: %v", err)
+		}
+		if attempt >= c.retryAttempts || !contains(c.retryOn, resp.StatusCode) {
+			return resp, nil
+		}
+		wait, ok := c.retryWait(resp, time.Duration(attempt)*retryBackoff)
+		if !ok {
+			return resp, nil
+		}
+		drainAndClose(resp.Body, c.readLimit)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, fmt.Errorf("performing request: %v", err)
+		}
+	}
+}
+
+// retryAfter returns how long the response's Retry-After header says
+// to wait, or fallback when it does not say.
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(t); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return fallback
+}
+
+// retryWait returns how long to wait before retrying after resp, which
+// is what its Retry-After header says or else fallback. It reports
+// false when the header asks for a longer wait than the longest one
+// WithRetryBackoff allows, in which case it is not worth retrying.
+func (c *config) retryWait(resp *http.Response, fallback time.Duration) (time.Duration, bool) {
+	if fallback > c.retryMax {
+		fallback = c.retryMax
+	}
+	wait := retryAfter(resp, fallback)
+	return wait, wait <= c.retryMax
+}
+
+// sleep waits for d to pass or ctx to be done, whichever comes first,
+// and returns ctx's error in the latter case.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// DoWithRetry performs the request with client (http.DefaultClient if
+// it is nil) and parses the response just like Do does, except that a
+// failed attempt is retried. An attempt fails when the request cannot
+// be performed at all, like when the connection is refused, or when the
+// response's status code is one of those given to WithRetry. Without
+// WithRetry there are up to 3 attempts and 429, 502, 503 and 504 are
+// retried. A Retry-After header says how long to wait before the next
+// attempt, otherwise the wait grows exponentially as set by
+// WithRetryBackoff. Once the attempts run out, or a Retry-After asks
+// for a longer wait than WithRetryBackoff allows, the last response is
+// parsed as usual, which for a retryable status code means a
+// *StatusError.
+//
+// Only idempotent requests are retried: those with a GET, HEAD,
+// OPTIONS, TRACE, PUT or DELETE method and those with an
+// Idempotency-Key header. A request with a body also needs a GetBody,
+// which http.NewRequest sets for the usual kinds of bodies. Any other
+// request is performed once.
+func DoWithRetry(ctx context.Context, client *http.Client, req *http.Request, wantStatuses []int, v interface{}, opts ...Option) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	c := newConfig(opts)
+	attempts, on := c.retryAttempts, c.retryOn
+	if attempts == 0 {
+		attempts, on = defaultRetryAttempts, defaultRetryOn
+	}
+	if !retryable(req) {
+		attempts = 1
+	}
+	parse := func(resp *http.Response) error {
+		return JSONContext(ctx, resp, wantStatuses, v, opts...)
+	}
+	for attempt := 1; ; attempt++ {
+		if err := c.allow(); err != nil {
+			return err
+		}
+		r, err := retryRequest(ctx, req, attempt)
+		if err != nil {
+			return err
+		}
+		resp, err := c.send(ctx, client, r)
+		last := attempt >= attempts
+		if err != nil && (last || ctx.Err() != nil) {
+			return fmt.Errorf("performing request: %v", err)
+		}
+		if err == nil && (last || !contains(on, resp.StatusCode)) {
+			return c.retryDecodeError(ctx, client, req, parse(resp), parse)
+		}
+		wait := c.backoff(attempt)
+		if resp != nil {
+			var ok bool
+			if wait, ok = c.retryWait(resp, wait); !ok {
+				return parse(resp)
+			}
+			drainAndClose(resp.Body, c.readLimit)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return fmt.Errorf("performing request: %v", err)
+		}
+	}
+}
+
+// retryable reports whether req may be performed more than once.
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryDecodeError performs req once more and returns what parse makes
+// of the new response when err, from parsing the first response, is a
+// *DecodeError and WithRetryOnDecodeError says to retry. Otherwise err
+// is returned as is.
+func (c *config) retryDecodeError(ctx context.Context, client *http.Client, req *http.Request, err error, parse func(*http.Response) error) error {
+	var decodeErr *DecodeError
+	if !c.retryDecode || !retryable(req) || !errors.As(err, &decodeErr) {
+		return err
+	}
+	if err := c.allow(); err != nil {
+		return err
+	}
+	r, err := retryRequest(ctx, req, 2)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(ctx, client, r)
+	if err != nil {
+		return fmt.Errorf("performing request: %v", err)
+	}
+	return parse(resp)
+}
+
+// retryRequest returns the request to perform for the given attempt,
+// with a fresh body for every attempt after the first.
+func retryRequest(ctx context.Context, req *http.Request, attempt int) (*http.Request, error) {
+	r := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("recreating request body: %v", err)
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// backoff returns how long to wait after the given attempt failed.
+func (c *config) backoff(attempt int) time.Duration {
+	wait := c.retryBase
+	for i := 1; i < attempt && wait < c.retryMax; i++ {
+		wait *= 2
+	}
+	if wait > c.retryMax {
+		wait = c.retryMax
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
@@ -0,0 +1,330 @@
+package httpparse_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRetry tests that requests are retried on the given status codes.
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		doErr        error
+		opts         []httpparse.Option
+		wantAttempts int
+		wantData     structuredJSON
+		wantErr      string
+	}{
+		{
+			name:         "no retries without the option",
+			statuses:     []int{503, 200},
+			opts:         nil,
+			wantAttempts: 1,
+			wantErr:      "got status code 503 but wanted 200, body: attempt 1",
+		},
+		{
+			name:         "retried until successful",
+			statuses:     []int{429, 503, 200},
+			opts:         []httpparse.Option{httpparse.WithRetry(3, []int{429, 503})},
+			wantAttempts: 3,
+			wantData:     structuredJSON{ValueOne: "attempt 3"},
+		},
+		{
+			name:         "not retried on other status codes",
+			statuses:     []int{404, 200},
+			opts:         []httpparse.Option{httpparse.WithRetry(3, []int{429, 503})},
+			wantAttempts: 1,
+			wantErr:      "got status code 404 but wanted 200, body: attempt 1",
+		},
+		{
+			name:         "attempts exhausted",
+			statuses:     []int{503, 503, 503, 200},
+			opts:         []httpparse.Option{httpparse.WithRetry(3, []int{503})},
+			wantAttempts: 3,
+			wantErr:      "got status code 503 but wanted 200, body: attempt 3",
+		},
+		{
+			name:         "request fails",
+			doErr:        errors.New("connection refused"),
+			opts:         []httpparse.Option{httpparse.WithRetry(3, []int{503})},
+			wantAttempts: 1,
+			wantErr:      "performing request: connection refused",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			resp, err := httpparse.Retry(func() (*http.Response, error) {
+				attempts++
+				if test.doErr != nil {
+					return nil, test.doErr
+				}
+				status := test.statuses[attempts-1]
+				body := fmt.Sprintf("attempt %d", attempts)
+				if status == 200 {
+					body = fmt.Sprintf(`{"value_one":%q}`, body)
+				}
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"Retry-After": {"0"}},
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+				}, nil
+			}, test.opts...)
+			var data structuredJSON
+			if err == nil {
+				err = httpparse.JSON(resp, []int{200}, &data, test.opts...)
+			}
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := attempts, test.wantAttempts; got != want {
+				t.Errorf("got %d attempts, wanted %d", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestRetryAfter tests that the Retry-After header says how long to
+// wait before retrying.
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name         string
+		retryAfter   string
+		wantWait     bool
+		wantAttempts int
+	}{
+		{name: "zero seconds", retryAfter: "0", wantWait: false, wantAttempts: 2},
+		{name: "date in the past", retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT", wantWait: false, wantAttempts: 2},
+		{name: "one second", retryAfter: "1", wantWait: true, wantAttempts: 2},
+		{name: "longer than the longest wait", retryAfter: "3600", wantWait: false, wantAttempts: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			start := time.Now()
+			resp, err := httpparse.Retry(func() (*http.Response, error) {
+				attempts++
+				return &http.Response{
+					StatusCode: 503,
+					Header:     http.Header{"Retry-After": {test.retryAfter}},
+					Body:       ioutil.NopCloser(strings.NewReader("")),
+				}, nil
+			}, httpparse.WithRetry(2, []int{503}))
+			if err != nil {
+				t.Fatalf("got a non-nil error: %v", err)
+			}
+			resp.Body.Close()
+			if got, want := time.Since(start) >= time.Second, test.wantWait; got != want {
+				t.Errorf("got waited a second %v, wanted %v", got, want)
+			}
+			if got, want := attempts, test.wantAttempts; got != want {
+				t.Errorf("got %d attempts, wanted %d", got, want)
+			}
+		})
+	}
+}
+
+// TestRetryContext tests that waiting to retry stops once the context
+// is done.
+func TestRetryContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := httpparse.RetryContext(ctx, func() (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 503,
+			Header:     http.Header{"Retry-After": {"5"}},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+		}, nil
+	}, httpparse.WithRetry(2, []int{503}))
+
+	if got, want := fmt.Sprintf("%v", err), "performing request: context deadline exceeded"; got != want {
+		t.Errorf("got error %s, wanted %s", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v to give up, wanted it to stop when the context was done", elapsed)
+	}
+}
+
+// roundTripFunc lets a function be a http.RoundTripper.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// TestDoWithRetry tests that failed attempts are retried.
+func TestDoWithRetry(t *testing.T) {
+	errRefused := errors.New("connection refused")
+	tests := []struct {
+		name         string
+		method       string
+		header       http.Header
+		body         string
+		retryAfter   string
+		results      []interface{}
+		opts         []httpparse.Option
+		wantAttempts int
+		wantData     structuredJSON
+		wantErr      string
+	}{
+		{
+			name:         "retried on the default status codes",
+			results:      []interface{}{429, 504, 200},
+			wantAttempts: 3,
+			wantData:     structuredJSON{ValueOne: "attempt 3"},
+		},
+		{
+			name:         "retried on transport errors",
+			results:      []interface{}{errRefused, 200},
+			wantAttempts: 2,
+			wantData:     structuredJSON{ValueOne: "attempt 2"},
+		},
+		{
+			name:         "default attempts exhausted",
+			results:      []interface{}{503, 503, 503, 200},
+			wantAttempts: 3,
+			wantErr:      "got status code 503 but wanted 200, body: attempt 3",
+		},
+		{
+			name:         "transport errors until the attempts are exhausted",
+			results:      []interface{}{errRefused, errRefused},
+			opts:         []httpparse.Option{httpparse.WithRetry(2, []int{503})},
+			wantAttempts: 2,
+			wantErr:      "performing request: Get \"http://example.com/things\": connection refused",
+		},
+		{
+			name:         "not retried when Retry-After asks for too long a wait",
+			retryAfter:   "3600",
+			results:      []interface{}{503, 200},
+			wantAttempts: 1,
+			wantErr:      "got status code 503 but wanted 200, body: attempt 1",
+		},
+		{
+			name:         "not retried on other status codes",
+			results:      []interface{}{500, 200},
+			wantAttempts: 1,
+			wantErr:      "got status code 500 but wanted 200, body: attempt 1",
+		},
+		{
+			name:         "non-idempotent requests are not retried",
+			method:       "POST",
+			body:         "{}",
+			results:      []interface{}{503, 200},
+			wantAttempts: 1,
+			wantErr:      "got status code 503 but wanted 200, body: attempt 1",
+		},
+		{
+			name:         "requests with an idempotency key are retried with their body",
+			method:       "POST",
+			header:       http.Header{"Idempotency-Key": {"abc"}},
+			body:         "{}",
+			results:      []interface{}{503, 200},
+			wantAttempts: 2,
+			wantData:     structuredJSON{ValueOne: "attempt 2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attempts := 0
+			client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if req.Body != nil {
+					if body, _ := ioutil.ReadAll(req.Body); string(body) != test.body {
+						t.Errorf("attempt %d got request body %q, wanted %q", attempts, body, test.body)
+					}
+				}
+				result := test.results[attempts-1]
+				if err, ok := result.(error); ok {
+					return nil, err
+				}
+				body := fmt.Sprintf("attempt %d", attempts)
+				if result == 200 {
+					body = fmt.Sprintf(`{"value_one":%q}`, body)
+				}
+				header := http.Header{}
+				if test.retryAfter != "" {
+					header.Set("Retry-After", test.retryAfter)
+				}
+				return &http.Response{
+					StatusCode: result.(int),
+					Header:     header,
+					Body:       ioutil.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			})}
+			method := test.method
+			if method == "" {
+				method = "GET"
+			}
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			req, err := http.NewRequest(method, "http://example.com/things", body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+			opts := append([]httpparse.Option{httpparse.WithRetryBackoff(time.Millisecond, time.Millisecond)}, test.opts...)
+			var data structuredJSON
+			err = httpparse.DoWithRetry(context.Background(), client, req, []int{200}, &data, opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := attempts, test.wantAttempts; got != want {
+				t.Errorf("got %d attempts, wanted %d", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestDoWithRetryContext tests that waiting to retry stops once the
+// context is done.
+func TestDoWithRetryContext(t *testing.T) {
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: 503,
+			Header:     http.Header{"Retry-After": {"5"}},
+			Body:       ioutil.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	})}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest("GET", "http://example.com/things", nil)
+	start := time.Now()
+	var data structuredJSON
+	err := httpparse.DoWithRetry(ctx, client, req, []int{200}, &data)
+
+	if got, want := fmt.Sprintf("%v", err), "performing request: context deadline exceeded"; got != want {
+		t.Errorf("got error %s, wanted %s", got, want)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v to give up, wanted it to stop when the context was done", elapsed)
+	}
+}
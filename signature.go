@@ -0,0 +1,74 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// VerifyFunc verifies that signature, the raw value of the signature
+// header, is valid for the canonical form of a response body. Both
+// the signature algorithm and how the key gets resolved are up to the
+// implementation. The response is passed along so a key can be picked
+// based on it (a key id header for example), note that its body has
+// already been read and closed by the time this is called.
+type VerifyFunc func(resp *http.Response, canonical []byte, signature string) error
+
+// JSONSigned parses a http response who's body contains JSON which
+// the server signed. The body is read (subject to the same limit as
+// RawBody), converted to its canonical form with CanonicalJSON and
+// the signature found in the signatureHeader is checked against it by
+// verify. A body in a charset other than UTF-8 is transcoded before it
+// is canonicalized. Only once the signature checks out is the body
+// unmarshalled into v, the way JSON would do it.
+func JSONSigned(resp *http.Response, wantStatus int, v interface{}, signatureHeader string, verify VerifyFunc, opts ...Option) error {
+	body, err := RawBody(resp, []int{wantStatus}, opts...)
+	if err != nil {
+		return err
+	}
+	body, err = transcodeBytes(body, resp)
+	if err != nil {
+		return err
+	}
+	signature := resp.Header.Get(signatureHeader)
+	if signature == "" {
+		return fmt.Errorf("response is missing the signature header %s", signatureHeader)
+	}
+	canonical, err := CanonicalJSON(body)
+	if err != nil {
+		return fmt.Errorf("canonicalizing response body: %v", err)
+	}
+	if err := verify(resp, canonical, signature); err != nil {
+		return fmt.Errorf("verifying response body signature: %v", err)
+	}
+	return DecodeJSONReader(bytes.NewReader(body), v, opts...)
+}
+
+// CanonicalJSON returns the canonical form of a JSON document: object
+// keys are sorted (byte-wise) and all insignificant whitespace is
+// removed. Numbers are kept exactly as they were written and strings
+// are re-escaped the way encoding/json escapes them (minus the HTML
+// escaping) so signers must canonicalize the same way for signatures
+// to match.
+func CanonicalJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the top level JSON value")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	// Encode() always terminates the value with a newline.
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
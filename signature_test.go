@@ -0,0 +1,176 @@
+package httpparse_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestCanonicalJSON tests that JSON documents are put into their
+// canonical form.
+func TestCanonicalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "keys are sorted and whitespace is removed",
+			json: "{\n  \"b\": [1, 2.50, {\"z\": null, \"a\": true}],\n  \"a\": \"<&>\"\n}\n",
+			want: `{"a":"<&>","b":[1,2.50,{"a":true,"z":null}]}`,
+		},
+		{
+			name:    "invalid JSON",
+			json:    `{"a":`,
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:    "trailing data",
+			json:    `{"a":1} {"b":2}`,
+			wantErr: "unexpected data after the top level JSON value",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := httpparse.CanonicalJSON([]byte(test.json))
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(got), test.want; got != want {
+				t.Errorf("got canonical JSON\n  %s\nwanted\n  %s", got, want)
+			}
+		})
+	}
+}
+
+func hmacVerifier(key string) httpparse.VerifyFunc {
+	return func(resp *http.Response, canonical []byte, signature string) error {
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(canonical)
+		got, err := hex.DecodeString(signature)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(got, mac.Sum(nil)) {
+			return errors.New("signature mismatch")
+		}
+		return nil
+	}
+}
+
+func hmacSign(key string, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestJSONSigned tests that a signed JSON response is only
+// unmarshalled when its signature is valid.
+func TestJSONSigned(t *testing.T) {
+	const body = `{ "value_two": 42, "value_one": "hello there" }`
+	const canonical = `{"value_one":"hello there","value_two":42}`
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "missing signature header",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "response is missing the signature header X-Signature",
+		},
+		{
+			name: "body is not JSON",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Signature": {"abc"}},
+				Body:       ioutil.NopCloser(strings.NewReader("lats")),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "canonicalizing response body: invalid character 'l'",
+		},
+		{
+			name: "invalid signature",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Signature": {hmacSign("wrong key", canonical)}},
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			},
+			wantData: structuredJSON{},
+			wantErr:  "verifying response body signature: signature mismatch",
+		},
+		{
+			name: "valid signature",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Signature": {hmacSign("secret", canonical)}},
+				Body:       ioutil.NopCloser(strings.NewReader(body)),
+			},
+			wantData: structuredJSON{ValueOne: "hello there", ValueTwo: 42},
+			wantErr:  "",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Signature": {hmacSign("secret", `{"value_two":"x"}`)}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":"x"}`)),
+			},
+			wantData: structuredJSON{},
+			wantErr:  `cannot unmarshal string into Go struct field structuredJSON.value_two of type int; first 17 bytes were: {"value_two":"x"}`,
+		},
+		{
+			name: "options are applied",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Signature": {hmacSign("secret", `{"other":1}`)}},
+				Body:       ioutil.NopCloser(strings.NewReader(`{"other":1}`)),
+			},
+			opts:     []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData: structuredJSON{},
+			wantErr:  `unknown field "other"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.JSONSigned(test.resp, 200, &data, "X-Signature", hmacVerifier("secret"), test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package httpparse
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single Server-Sent Event.
+type Event struct {
+	// ID is the last event ID the server sent, which carries over to
+	// later events until the server sends another one.
+	ID string
+	// Event is the event's type, "message" unless the server says
+	// otherwise.
+	Event string
+	// Data is the event's data with the lines of a multi-line event
+	// joined by "\n".
+	Data string
+	// Retry is the reconnection time the server last asked for, zero if
+	// it never did.
+	Retry time.Duration
+}
+
+// Events parses a http response who's body is a stream of Server-Sent
+// Events (Content-Type text/event-stream) and closes the response body.
+// The status code must be wantStatus and fn is called with every event
+// as it arrives. Returning an error from fn stops the parsing and that
+// error is returned as is. Otherwise Events returns nil once the server
+// ends the stream or, when ctx is done first, an error like "reading
+// response body: context canceled".
+//
+// Like JSONArray, it does not limit how much of the body gets read
+// since a stream of events can go on forever. Comment lines, which
+// servers often send as heartbeats to keep an idle connection open, are
+// never passed to fn but can be seen with WithCommentHook.
+func Events(ctx context.Context, resp *http.Response, wantStatus int, fn func(Event) error, opts ...Option) error {
+	defer abortOnDone(ctx, resp)()
+	c := newConfig(append(opts[:len(opts):len(opts)], func(c *config) { c.ctx = ctx }))
+	// The body is not drained since the rest of a live stream could
+	// take forever to arrive.
+	defer resp.Body.Close()
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		return err
+	}
+	if got, wants := resp.StatusCode, []int{wantStatus}; !contains(wants, got) {
+		return mismatchError(resp, r, c, wants)
+	}
+	if err := checkContentType(resp, "text/event-stream"); err != nil {
+		return err
+	}
+	return readEvents(r, fn, c.commentHook)
+}
+
+// readEvents parses the event stream in r as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html.
+// Comment lines are passed to onComment, if it is not nil.
+func readEvents(r io.Reader, fn func(Event) error, onComment func(comment string)) error {
+	var (
+		br    = bufio.NewReader(r)
+		last  Event
+		event string
+		data  strings.Builder
+	)
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return &ReadError{Err: err}
+		}
+		// An event which is not followed by a blank line before the
+		// stream ends is incomplete and gets dropped.
+		if err == io.EOF {
+			return nil
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if line == "" {
+			if data.Len() > 0 {
+				last.Event = event
+				if last.Event == "" {
+					last.Event = "message"
+				}
+				last.Data = strings.TrimSuffix(data.String(), "\n")
+				if err := fn(last); err != nil {
+					return err
+				}
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			if onComment != nil {
+				onComment(strings.TrimPrefix(line[1:], " "))
+			}
+			continue
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				last.ID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				last.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
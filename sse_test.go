@@ -0,0 +1,166 @@
+package httpparse_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestEvents tests that Server-Sent Events are parsed one by one.
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		stopAt     int
+		wantEvents []httpparse.Event
+		wantErr    string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Header:     http.Header{"Content-Type": {"text/plain"}},
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "not an event stream",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(strings.NewReader("{}")),
+			},
+			wantErr: "expected Content-Type text/event-stream but got application/json",
+		},
+		{
+			name: "error reading response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"text/event-stream"}},
+				Body:       errReadCloser{readErr: errors.New("some read err")},
+			},
+			wantErr: "reading response body: some read err",
+		},
+		{
+			name: "events",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"text/event-stream; charset=utf-8"}},
+				Body: ioutil.NopCloser(strings.NewReader(": a comment\n" +
+					"data: first\n\n" +
+					"event: update\r\nid: 7\r\nretry: 1500\r\ndata: second\r\ndata:  line two\r\n\r\n" +
+					"id\n\n" +
+					"data\n\n" +
+					"data: never finished\n")),
+			},
+			wantEvents: []httpparse.Event{
+				{Event: "message", Data: "first"},
+				{ID: "7", Event: "update", Data: "second\n line two", Retry: 1500 * time.Millisecond},
+				{Event: "message", Data: "", Retry: 1500 * time.Millisecond},
+			},
+		},
+		{
+			name: "callback stops early",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"text/event-stream"}},
+				Body:       ioutil.NopCloser(strings.NewReader("data: a\n\ndata: b\n\ndata: c\n\n")),
+			},
+			stopAt: 1,
+			wantEvents: []httpparse.Event{
+				{Event: "message", Data: "a"},
+			},
+			wantErr: "had enough",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var events []httpparse.Event
+			err := httpparse.Events(context.Background(), test.resp, 200, func(e httpparse.Event) error {
+				if test.stopAt != 0 && len(events) == test.stopAt {
+					return errors.New("had enough")
+				}
+				events = append(events, e)
+				return nil
+			})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := events, test.wantEvents; !reflect.DeepEqual(got, want) {
+				t.Errorf("got events %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestEventsContext tests that parsing events stops once the context is
+// done.
+func TestEventsContext(t *testing.T) {
+	// The server sends an event and then hangs.
+	pr, pw := io.Pipe()
+	go pw.Write([]byte("data: hi\n\n"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body:       pr,
+	}
+	var events []httpparse.Event
+	err := httpparse.Events(ctx, resp, 200, func(e httpparse.Event) error {
+		events = append(events, e)
+		return nil
+	})
+
+	if got, want := fmt.Sprintf("%v", err), "reading response body: context deadline exceeded"; got != want {
+		t.Errorf("got error %s, wanted %s", got, want)
+	}
+	if got, want := events, []httpparse.Event{{Event: "message", Data: "hi"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %+v, wanted %+v", got, want)
+	}
+}
+
+// TestEventsComments tests that comment lines, like heartbeats, are
+// passed to the comment hook and not to the event callback.
+func TestEventsComments(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"Content-Type": {"text/event-stream"}},
+		Body: ioutil.NopCloser(strings.NewReader(": heartbeat\n\n" +
+			"data: first\n:keep-alive\ndata: second\n\n" +
+			":\n\n")),
+	}
+	var (
+		events   []httpparse.Event
+		comments []string
+	)
+	err := httpparse.Events(context.Background(), resp, 200, func(e httpparse.Event) error {
+		events = append(events, e)
+		return nil
+	}, httpparse.WithCommentHook(func(comment string) {
+		comments = append(comments, comment)
+	}))
+
+	if err != nil {
+		t.Errorf("got a non-nil error: %v", err)
+	}
+	if got, want := events, []httpparse.Event{{Event: "message", Data: "first\nsecond"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got events %+v, wanted %+v", got, want)
+	}
+	if got, want := comments, []string{"heartbeat", "keep-alive", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got comments %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package httpparse
+
+import "net/http"
+
+// Is2xx reports whether code is a successful (2xx) status code. It is
+// meant to be passed to RawBodyFunc and is the same as Status2xx.
+func Is2xx(code int) bool {
+	return Status2xx(code)
+}
+
+// Status1xx, Status2xx, Status3xx, Status4xx and Status5xx report
+// whether code is of their class of status codes. They are meant to be
+// passed to RawBodyFunc or WithStatusMatcher.
+func Status1xx(code int) bool { return code/100 == 1 }
+func Status2xx(code int) bool { return code/100 == 2 }
+func Status3xx(code int) bool { return code/100 == 3 }
+func Status4xx(code int) bool { return code/100 == 4 }
+func Status5xx(code int) bool { return code/100 == 5 }
+
+// StatusRange returns a predicate reporting whether a status code is
+// between lo and hi, inclusive.
+func StatusRange(lo, hi int) func(code int) bool {
+	return func(code int) bool {
+		return lo <= code && code <= hi
+	}
+}
+
+// StatusNot returns a predicate reporting whether match does not
+// accept a status code, e.g. StatusNot(Status5xx) for anything but a
+// server error.
+func StatusNot(match func(code int) bool) func(code int) bool {
+	return func(code int) bool {
+		return !match(code)
+	}
+}
+
+// acceptsStatus reports whether the status matcher, if there is one,
+// accepts code.
+func (c *config) acceptsStatus(code int) bool {
+	return c.statusMatcher != nil && c.statusMatcher(code)
+}
+
+// RawBodyFunc is RawBody for when listing every acceptable status code
+// gets tedious. A status code is acceptable when accept returns true
+// for it:
+//
+//	body, err := httpparse.RawBodyFunc(resp, httpparse.Is2xx)
+//
+// Since there is no list of wanted status codes the error for an
+// unacceptable one reads "got unexpected status code %d, body: ...".
+func RawBodyFunc(resp *http.Response, accept func(code int) bool, opts ...Option) ([]byte, error) {
+	return rawBody(resp, accept, nil, opts)
+}
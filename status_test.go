@@ -0,0 +1,200 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestRawBodyFunc tests that the predicate decides which status codes
+// are acceptable.
+func TestRawBodyFunc(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		accept     func(code int) bool
+		wantBody   string
+		wantErr    string
+	}{
+		{
+			name:       "2xx accepted",
+			statusCode: 204,
+			accept:     httpparse.Is2xx,
+			wantBody:   "hello there",
+		},
+		{
+			name:       "3xx rejected",
+			statusCode: 304,
+			accept:     httpparse.Is2xx,
+			wantErr:    "got unexpected status code 304, body: hello there",
+		},
+		{
+			name:       "1xx rejected",
+			statusCode: 199,
+			accept:     httpparse.Is2xx,
+			wantErr:    "got unexpected status code 199, body: hello there",
+		},
+		{
+			name:       "custom predicate",
+			statusCode: 404,
+			accept:     func(code int) bool { return code == 200 || code == 404 },
+			wantBody:   "hello there",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			}
+			body, err := httpparse.RawBodyFunc(resp, test.accept)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestStatusMatchers tests the predicates for classes and ranges of
+// status codes.
+func TestStatusMatchers(t *testing.T) {
+	tests := []struct {
+		name  string
+		match func(code int) bool
+		codes []int
+		want  []bool
+	}{
+		{
+			name:  "1xx",
+			match: httpparse.Status1xx,
+			codes: []int{99, 100, 199, 200},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "2xx",
+			match: httpparse.Status2xx,
+			codes: []int{199, 200, 299, 300},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "3xx",
+			match: httpparse.Status3xx,
+			codes: []int{299, 304, 399, 400},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "4xx",
+			match: httpparse.Status4xx,
+			codes: []int{399, 404, 499, 500},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "5xx",
+			match: httpparse.Status5xx,
+			codes: []int{499, 500, 599, 600},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "range",
+			match: httpparse.StatusRange(200, 204),
+			codes: []int{199, 200, 204, 205},
+			want:  []bool{false, true, true, false},
+		},
+		{
+			name:  "anything but 5xx",
+			match: httpparse.StatusNot(httpparse.Status5xx),
+			codes: []int{200, 404, 500, 503},
+			want:  []bool{true, true, false, false},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, code := range test.codes {
+				if got, want := test.match(code), test.want[i]; got != want {
+					t.Errorf("got %t for status code %d, wanted %t", got, code, want)
+				}
+			}
+		})
+	}
+}
+
+// TestWithStatusMatcher tests that status codes accepted by the matcher
+// are wanted on top of those listed.
+func TestWithStatusMatcher(t *testing.T) {
+	tests := []struct {
+		name         string
+		statusCode   int
+		wantStatuses []int
+		match        func(code int) bool
+		wantData     structuredJSON
+		wantErr      string
+	}{
+		{
+			name:         "matched",
+			statusCode:   201,
+			wantStatuses: nil,
+			match:        httpparse.Status2xx,
+			wantData:     structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:         "listed but not matched",
+			statusCode:   404,
+			wantStatuses: []int{404},
+			match:        httpparse.Status2xx,
+			wantData:     structuredJSON{ValueTwo: 42},
+		},
+		{
+			name:         "neither listed nor matched",
+			statusCode:   500,
+			wantStatuses: nil,
+			match:        httpparse.StatusNot(httpparse.Status5xx),
+			wantErr:      `got unexpected status code 500, body: {"value_two":42}`,
+		},
+		{
+			name:         "no matcher",
+			statusCode:   201,
+			wantStatuses: []int{200},
+			match:        nil,
+			wantErr:      `got status code 201 but wanted 200`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := func() *http.Response {
+				return &http.Response{
+					StatusCode: test.statusCode,
+					Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}`)),
+				}
+			}
+			opts := []httpparse.Option{httpparse.WithStatusMatcher(test.match)}
+
+			var data structuredJSON
+			err := httpparse.JSON(resp(), test.wantStatuses, &data, opts...)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("JSON: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("JSON: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("JSON: got data %+v, wanted %+v", got, want)
+			}
+
+			_, err = httpparse.RawBody(resp(), test.wantStatuses, opts...)
+			if test.wantErr == "" && err != nil {
+				t.Errorf("RawBody: got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("RawBody: got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+		})
+	}
+}
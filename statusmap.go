@@ -0,0 +1,82 @@
+package httpparse
+
+import (
+	"net/http"
+	"reflect"
+)
+
+// StatusMap says where the JSON body of a response gets decoded
+// depending on its status code, which saves you from an if/else tree
+// around every call to an API which answers with different bodies for
+// different status codes:
+//
+//	var user User
+//	var apiErr APIError
+//	i, err := httpparse.StatusMap{
+//		{Status: 200, Into: &user},
+//		{Status: 404},
+//		{Match: httpparse.Status4xx, Into: &apiErr},
+//	}.JSON(resp)
+//
+// The first case matching the status code wins.
+type StatusMap []StatusCase
+
+// StatusCase is a case of a StatusMap. It matches a status code equal
+// to Status or, when Status is 0, one which Match accepts. The body of
+// a matching response is decoded into Into, a nil Into (or a nil
+// pointer) means the body is not wanted.
+type StatusCase struct {
+	Status int
+	Match  func(code int) bool
+	Into   interface{}
+}
+
+// matches reports whether the case matches the status code.
+func (s StatusCase) matches(code int) bool {
+	if s.Status != 0 {
+		return s.Status == code
+	}
+	return s.Match != nil && s.Match(code)
+}
+
+// JSON decodes the body of resp, which it closes, into the Into of the
+// first case matching the response's status code and returns the index
+// of that case. Decoding works just like JSON, options included. When
+// no case matches it returns -1 along with the error JSON would return
+// for an unwanted status code, or no error when WithStatusMatcher
+// accepts the status code, in which case there is nothing to decode
+// the body into so it is drained.
+func (m StatusMap) JSON(resp *http.Response, opts ...Option) (int, error) {
+	c := newConfig(opts)
+	for i, s := range m {
+		if !s.matches(resp.StatusCode) {
+			continue
+		}
+		if isNil(s.Into) {
+			drainAndClose(resp.Body, c.readLimit)
+			return i, nil
+		}
+		return i, JSON(resp, []int{resp.StatusCode}, s.Into, opts...)
+	}
+	if c.acceptsStatus(resp.StatusCode) {
+		drainAndClose(resp.Body, c.readLimit)
+		return -1, nil
+	}
+	var wants []int
+	for _, s := range m {
+		if s.Status != 0 {
+			wants = append(wants, s.Status)
+		}
+	}
+	return -1, JSON(resp, wants, nil, opts...)
+}
+
+// isNil reports whether v is nil or a nil pointer, neither of which can
+// be decoded into.
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
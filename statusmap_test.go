@@ -0,0 +1,105 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestStatusMap tests that the body is decoded into the target of the
+// first case matching the status code.
+func TestStatusMap(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		opts       []httpparse.Option
+		wantIndex  int
+		wantData   structuredJSON
+		wantAPIErr structuredJSON
+		wantErr    string
+	}{
+		{
+			name:       "exact status code",
+			statusCode: 200,
+			body:       `{"value_one":"hi"}`,
+			wantIndex:  0,
+			wantData:   structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:       "exact status code wins over a later class",
+			statusCode: 404,
+			body:       `not json`,
+			wantIndex:  1,
+		},
+		{
+			name:       "class",
+			statusCode: 422,
+			body:       `{"value_one":"bad"}`,
+			wantIndex:  3,
+			wantAPIErr: structuredJSON{ValueOne: "bad"},
+		},
+		{
+			name:       "nil pointer target",
+			statusCode: 410,
+			body:       `{"value_one":"gone"}`,
+			wantIndex:  2,
+		},
+		{
+			name:       "decoding fails",
+			statusCode: 200,
+			body:       `{"value_two":"hi"}`,
+			wantIndex:  0,
+			wantErr:    "unmarshalling response body: json: cannot unmarshal string",
+		},
+		{
+			name:       "no case matches",
+			statusCode: 500,
+			body:       `oops`,
+			wantIndex:  -1,
+			wantErr:    "got status code 500 but wanted one of [200 404 410], body: oops",
+		},
+		{
+			name:       "no case matches but the status matcher accepts it",
+			statusCode: 503,
+			body:       `{"value_one":"busy"}`,
+			opts:       []httpparse.Option{httpparse.WithStatusMatcher(httpparse.Status5xx)},
+			wantIndex:  -1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data, apiErr structuredJSON
+			var gone *structuredJSON
+			i, err := httpparse.StatusMap{
+				{Status: 200, Into: &data},
+				{Status: 404},
+				{Status: 410, Into: gone},
+				{Match: httpparse.Status4xx, Into: &apiErr},
+			}.JSON(resp, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := i, test.wantIndex; got != want {
+				t.Errorf("got index %d, wanted %d", got, want)
+			}
+			if got, want := data, test.wantData; test.wantErr == "" && got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if got, want := apiErr, test.wantAPIErr; got != want {
+				t.Errorf("got API error %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package httpparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// errStopped is how JSONElements stops JSONArray when the loop over it
+// is broken out of.
+var errStopped = errors.New("iteration stopped")
+
+// maxStreamDrain is how much of a streamed body gets drained before it
+// is closed. A stream which was parsed to the end has nothing left, one
+// which was stopped early could have gigabytes left and reading those
+// just to keep the connection is not worth it.
+const maxStreamDrain = 64 << 10
+
+// JSONArray parses a http response who's body is a JSON array one
+// element at a time, for arrays too big to hold in memory at once. fn
+// is called for every element and decode decodes that element into
+// whatever you pass it, so each one can be processed and thrown away
+// before the next one is read:
+//
+//	err := httpparse.JSONArray(resp, 200, func(decode func(interface{}) error) error {
+//		var rec Record
+//		if err := decode(&rec); err != nil {
+//			return err
+//		}
+//		return store(rec)
+//	})
+//
+// An element fn does not decode is skipped. Returning an error from fn
+// stops the parsing and that error is returned as is.
+//
+// Be aware that this is the one function in this package which does
+// not limit how much of the body gets read. The whole point is to
+// handle bodies which are too big for that limit, so it is up to fn to
+// give up if there are more elements than it is willing to process.
+func JSONArray(resp *http.Response, wantStatus int, fn func(decode func(v interface{}) error) error) error {
+	c := newConfig(nil)
+	defer drainAndClose(resp.Body, maxStreamDrain)
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		return err
+	}
+	if got, wants := resp.StatusCode, []int{wantStatus}; !contains(wants, got) {
+		return mismatchError(resp, r, c, wants)
+	}
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for i := 0; dec.More(); i++ {
+		decoded := false
+		var decodeErr error
+		err := fn(func(v interface{}) error {
+			if decoded {
+				return fmt.Errorf("element %d has already been decoded", i)
+			}
+			decoded = true
+			if err := dec.Decode(v); err != nil {
+				decodeErr = &DecodeError{Err: fmt.Errorf("element %d: %v", i, err)}
+			}
+			return decodeErr
+		})
+		// Once decoding fails the decoder cannot go on, even if fn
+		// ignored the error.
+		if decodeErr != nil {
+			return decodeErr
+		}
+		if err != nil {
+			return err
+		}
+		if !decoded {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return &DecodeError{Err: fmt.Errorf("element %d: %v", i, err)}
+			}
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// JSONElements is JSONArray as an iterator, decoding every element of
+// the array into a T:
+//
+//	for rec, err := range httpparse.JSONElements[Record](resp, 200) {
+//		if err != nil {
+//			return err
+//		}
+//		store(rec)
+//	}
+//
+// An error, be it about the status code, the body or an element, ends
+// the iteration so it comes with the last pair. Breaking out of the
+// loop early stops the parsing. The body can only be read once so
+// neither can the iterator.
+func JSONElements[T any](resp *http.Response, wantStatus int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		err := JSONArray(resp, wantStatus, func(decode func(v interface{}) error) error {
+			var v T
+			if err := decode(&v); err != nil {
+				return err
+			}
+			if !yield(v, nil) {
+				return errStopped
+			}
+			return nil
+		})
+		if err != nil && err != errStopped {
+			var zero T
+			yield(zero, err)
+		}
+	}
+}
+
+// expectDelim reads the next token from dec which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+	if tok != delim {
+		return &DecodeError{Err: fmt.Errorf("expected %v but got %v", delim, tok)}
+	}
+	return nil
+}
+
+// JSONLines parses a http response who's body is newline delimited JSON
+// (also known as NDJSON or JSON Lines) one line at a time, for bodies
+// too big to hold in memory at once. It works just like JSONArray: fn
+// is called for every line and decode decodes that line into whatever
+// you pass it. Blank lines are skipped and line numbers in errors start
+// at 1. Like JSONArray, it does not limit how much of the body gets
+// read.
+func JSONLines(resp *http.Response, wantStatus int, fn func(decode func(v interface{}) error) error) error {
+	c := newConfig(nil)
+	defer drainAndClose(resp.Body, maxStreamDrain)
+	r, err := bodyReader(resp, c)
+	if err != nil {
+		return err
+	}
+	if got, wants := resp.StatusCode, []int{wantStatus}; !contains(wants, got) {
+		return mismatchError(resp, r, c, wants)
+	}
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return &ReadError{Err: readErr}
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			decoded := false
+			err := fn(func(v interface{}) error {
+				if decoded {
+					return fmt.Errorf("line %d has already been decoded", n)
+				}
+				decoded = true
+				if err := json.Unmarshal(line, v); err != nil {
+					return &DecodeError{Err: fmt.Errorf("line %d: %v", n, err)}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,372 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONArray tests that JSON arrays are parsed element by element.
+func TestJSONArray(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		stopAt      int
+		skip        int
+		wantRecords []structuredJSON
+		wantErr     string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "not an array",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi"}`)),
+			},
+			wantErr: "unmarshalling response body: expected [ but got {",
+		},
+		{
+			name: "invalid JSON",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`lats`)),
+			},
+			wantErr: "unmarshalling response body: invalid character 'l'",
+		},
+		{
+			name: "array which never ends",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"value_one":"a"}`)),
+			},
+			wantRecords: []structuredJSON{{ValueOne: "a"}},
+			wantErr:     "unmarshalling response body: element 1: unexpected end of JSON input",
+		},
+		{
+			name: "element which does not decode",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"value_one":"a"},{"value_two":"b"}]`)),
+			},
+			wantRecords: []structuredJSON{{ValueOne: "a"}},
+			wantErr:     "unmarshalling response body: element 1: json: cannot unmarshal string",
+		},
+		{
+			name: "callback stops early",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"value_one":"a"},{"value_one":"b"},{"value_one":"c"}]`)),
+			},
+			stopAt:      2,
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueOne: "b"}},
+			wantErr:     "had enough",
+		},
+		{
+			name: "undecoded elements are skipped",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`[{"value_one":"a"},{"value_one":"b"},{"value_one":"c"}]`)),
+			},
+			skip:        2,
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueOne: "c"}},
+		},
+		{
+			name: "empty array",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(` [ ] `)),
+			},
+			wantRecords: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var records []structuredJSON
+			n := 0
+			err := httpparse.JSONArray(test.resp, 200, func(decode func(interface{}) error) error {
+				n++
+				if n == test.skip {
+					return nil
+				}
+				if test.stopAt != 0 && n > test.stopAt {
+					return errors.New("had enough")
+				}
+				var rec structuredJSON
+				if err := decode(&rec); err != nil {
+					return err
+				}
+				records = append(records, rec)
+				return nil
+			})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := records, test.wantRecords; !reflect.DeepEqual(got, want) {
+				t.Errorf("got records %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestJSONLines tests that newline delimited JSON is parsed line by
+// line.
+func TestJSONLines(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		stopAt      int
+		wantRecords []structuredJSON
+		wantErr     string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "error reading response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       errReadCloser{readErr: errors.New("some read err")},
+			},
+			wantErr: "reading response body: some read err",
+		},
+		{
+			name: "line which does not decode",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("{\"value_one\":\"a\"}\n\n{\"value_two\":\"b\"}\n")),
+			},
+			wantRecords: []structuredJSON{{ValueOne: "a"}},
+			wantErr:     "unmarshalling response body: line 3: json: cannot unmarshal string",
+		},
+		{
+			name: "callback stops early",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("{\"value_one\":\"a\"}\n{\"value_one\":\"b\"}\n{\"value_one\":\"c\"}\n")),
+			},
+			stopAt:      2,
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueOne: "b"}},
+			wantErr:     "had enough",
+		},
+		{
+			name: "blank lines, CRLFs and no final newline",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("\r\n{\"value_one\":\"a\"}\r\n  \n{\"value_two\":42}")),
+			},
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueTwo: 42}},
+		},
+		{
+			name: "empty body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("")),
+			},
+			wantRecords: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var records []structuredJSON
+			n := 0
+			err := httpparse.JSONLines(test.resp, 200, func(decode func(interface{}) error) error {
+				n++
+				if test.stopAt != 0 && n > test.stopAt {
+					return errors.New("had enough")
+				}
+				var rec structuredJSON
+				if err := decode(&rec); err != nil {
+					return err
+				}
+				records = append(records, rec)
+				return nil
+			})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := records, test.wantRecords; !reflect.DeepEqual(got, want) {
+				t.Errorf("got records %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestJSONElements tests that JSON arrays can be ranged over element
+// by element.
+func TestJSONElements(t *testing.T) {
+	tests := []struct {
+		name        string
+		statusCode  int
+		body        string
+		stopAt      int
+		wantRecords []structuredJSON
+		wantErr     string
+	}{
+		{
+			name:       "unexpected response status code",
+			statusCode: 500,
+			body:       "woa there",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:        "elements",
+			statusCode:  200,
+			body:        `[{"value_one":"a"},{"value_two":2}]`,
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueTwo: 2}},
+		},
+		{
+			name:        "empty array",
+			statusCode:  200,
+			body:        `[]`,
+			wantRecords: nil,
+		},
+		{
+			name:        "bad element",
+			statusCode:  200,
+			body:        `[{"value_one":"a"},{"value_two":"b"}]`,
+			wantRecords: []structuredJSON{{ValueOne: "a"}},
+			wantErr:     "unmarshalling response body: element 1: json: cannot unmarshal string",
+		},
+		{
+			name:        "not an array",
+			statusCode:  200,
+			body:        `{"value_one":"a"}`,
+			wantRecords: nil,
+			wantErr:     "unmarshalling response body: expected [ but got {",
+		},
+		{
+			name:        "breaking out early",
+			statusCode:  200,
+			body:        `[{"value_one":"a"},{"value_one":"b"},{"value_one":"c"}]`,
+			stopAt:      2,
+			wantRecords: []structuredJSON{{ValueOne: "a"}, {ValueOne: "b"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var records []structuredJSON
+			var err error
+			for rec, recErr := range httpparse.JSONElements[structuredJSON](resp, 200) {
+				if recErr != nil {
+					err = recErr
+					break
+				}
+				records = append(records, rec)
+				if len(records) == test.stopAt {
+					break
+				}
+			}
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := records, test.wantRecords; !reflect.DeepEqual(got, want) {
+				t.Errorf("got records %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// endlessBody is a response body which never ends: whatever buf starts
+// out with followed by elem over and over. It counts how many bytes get
+// read from it.
+type endlessBody struct {
+	buf  []byte
+	elem string
+	n    int
+}
+
+func (e *endlessBody) Read(b []byte) (int, error) {
+	for len(e.buf) < len(b) {
+		e.buf = append(e.buf, e.elem...)
+	}
+	n := copy(b, e.buf)
+	e.buf = e.buf[n:]
+	e.n += n
+	return n, nil
+}
+
+func (e *endlessBody) Close() error {
+	return nil
+}
+
+// TestStreamEarlyStop tests that only a little of what is left of a
+// stream gets drained once parsing it stops early.
+func TestStreamEarlyStop(t *testing.T) {
+	stop := func(decode func(interface{}) error) error {
+		return errors.New("had enough")
+	}
+	tests := []struct {
+		name  string
+		start string
+		elem  string
+		parse func(resp *http.Response) error
+	}{
+		{
+			name:  "JSONArray",
+			start: "[",
+			elem:  `{"value_one":"a"},`,
+			parse: func(resp *http.Response) error {
+				return httpparse.JSONArray(resp, 200, stop)
+			},
+		},
+		{
+			name: "JSONLines",
+			elem: `{"value_one":"a"}` + "\n",
+			parse: func(resp *http.Response) error {
+				return httpparse.JSONLines(resp, 200, stop)
+			},
+		},
+		{
+			name:  "JSONElements",
+			start: "[",
+			elem:  `{"value_one":"a"},`,
+			parse: func(resp *http.Response) error {
+				for range httpparse.JSONElements[structuredJSON](resp, 200) {
+					break
+				}
+				return nil
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := &endlessBody{buf: []byte(test.start), elem: test.elem}
+			test.parse(&http.Response{StatusCode: 200, Body: body})
+
+			if got, max := body.n, 1<<20; got > max {
+				t.Errorf("got %d bytes read, wanted no more than %d", got, max)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package httpparse
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+)
+
+// UnsuccessfulError is returned by JSONRequireSuccess when a response
+// has the expected status code but its body says the request failed.
+type UnsuccessfulError struct {
+	// Field is the dotted path of the success field.
+	Field string
+	// Body is the whole raw response body, of which the message only
+	// shows the beginning.
+	Body []byte
+}
+
+func (e *UnsuccessfulError) Error() string {
+	body, cut := previewBody(e.Body, defaultPreviewSize)
+	return fmt.Sprintf("response field %q is false, body: %s%s", e.Field, body, ellipsis(cut))
+}
+
+// JSONRequireSuccess parses a http response who's body contains JSON
+// for APIs which always respond with a successful status code and
+// instead indicate failure with a boolean field in the body, e.g.
+// {"success":false,"error":"..."}. The field is found by following
+// successField, a dotted path like "meta.ok". If it is false an
+// *UnsuccessfulError is returned and v is left alone, otherwise the
+// body is unmarshalled into v just like JSON would. Since the body has
+// to be checked before it is decoded it is read into memory first.
+func JSONRequireSuccess(resp *http.Response, wantStatus int, successField string, v interface{}, opts ...Option) error {
+	body, err := RawBody(resp, []int{wantStatus}, opts...)
+	if err != nil {
+		return err
+	}
+	body, err = transcodeBytes(body, resp)
+	if err != nil {
+		return err
+	}
+	val, err := lookupPath(body, successField)
+	if err != nil {
+		return err
+	}
+	success, ok := val.(bool)
+	if !ok {
+		preview, cut := previewBody(body, defaultPreviewSize)
+		return fmt.Errorf("response field %q is not a boolean, body: %s%s", successField, preview, ellipsis(cut))
+	}
+	if !success {
+		return &UnsuccessfulError{Field: successField, Body: body}
+	}
+	return DecodeJSONReader(bytes.NewReader(body), v, opts...)
+}
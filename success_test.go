@@ -0,0 +1,146 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONRequireSuccess tests that a response whose body reports a
+// failure results in an error.
+func TestJSONRequireSuccess(t *testing.T) {
+	huge := `{"success":false,"error":"` + strings.Repeat("x", 1000) + `"}`
+	hugeString := `{"success":"` + strings.Repeat("x", 1000) + `"}`
+	tests := []struct {
+		name         string
+		resp         *http.Response
+		successField string
+		opts         []httpparse.Option
+		wantData     structuredJSON
+		wantErr      string
+		wantBody     string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			successField: "success",
+			wantData:     structuredJSON{},
+			wantErr:      "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "success field is missing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}`)),
+			},
+			successField: "success",
+			wantData:     structuredJSON{},
+			wantErr:      `response body has no value at path "success"`,
+		},
+		{
+			name: "success field is not a boolean",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"success":"yes"}`)),
+			},
+			successField: "success",
+			wantData:     structuredJSON{},
+			wantErr:      `response field "success" is not a boolean, body: {"success":"yes"}`,
+		},
+		{
+			name: "success field is false",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"meta":{"ok":false},"error":"nope"}`)),
+			},
+			successField: "meta.ok",
+			wantData:     structuredJSON{},
+			wantErr:      `response field "meta.ok" is false, body: {"meta":{"ok":false},"error":"nope"}`,
+			wantBody:     `{"meta":{"ok":false},"error":"nope"}`,
+		},
+		{
+			name: "huge body when the success field is not a boolean",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(hugeString)),
+			},
+			successField: "success",
+			wantData:     structuredJSON{},
+			wantErr:      `response field "success" is not a boolean, body: ` + hugeString[:512] + "...",
+		},
+		{
+			name: "huge body when the success field is false",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(huge)),
+			},
+			successField: "success",
+			wantData:     structuredJSON{},
+			wantErr:      `response field "success" is false, body: ` + huge[:512] + "...",
+			wantBody:     huge,
+		},
+		{
+			name: "success field is true",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"meta":{"ok":true},"value_two":42}`)),
+			},
+			successField: "meta.ok",
+			wantData:     structuredJSON{ValueTwo: 42},
+			wantErr:      "",
+		},
+		{
+			name: "options are applied",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"success":true,"other":1}`)),
+			},
+			successField: "success",
+			opts:         []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData:     structuredJSON{},
+			wantErr:      `unknown field "success"; first 26 bytes were: {"success":true,"other":1}`,
+		},
+		{
+			name: "charset is transcoded",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"application/json; charset=iso-8859-1"}},
+				Body:       ioutil.NopCloser(strings.NewReader("{\"success\":true,\"value_one\":\"caf\xe9\"}")),
+			},
+			successField: "success",
+			wantData:     structuredJSON{ValueOne: "caf\u00e9"},
+			wantErr:      "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.JSONRequireSuccess(test.resp, 200, test.successField, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if test.wantBody != "" {
+				uerr, ok := err.(*httpparse.UnsuccessfulError)
+				if !ok {
+					t.Fatalf("got error of type %T, wanted *httpparse.UnsuccessfulError", err)
+				}
+				if got, want := string(uerr.Body), test.wantBody; got != want {
+					t.Errorf("got error body %s, wanted %s", got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package httpparse
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// JSONWithBody is JSON but it also returns the body, for when it needs
+// to be cached, signed or logged verbatim. The body is decoded as it is
+// read like JSON does it, see WithTee for writing it somewhere other
+// than memory.
+func JSONWithBody(resp *http.Response, wantStatuses []int, v interface{}, opts ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := JSON(resp, wantStatuses, v, append(opts[:len(opts):len(opts)], WithTee(&buf))...); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+package httpparse_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONWithBody tests that the body is returned along with the
+// decoded JSON.
+func TestJSONWithBody(t *testing.T) {
+	tests := []struct {
+		name            string
+		statusCode      int
+		contentEncoding string
+		body            string
+		opts            []httpparse.Option
+		wantData        structuredJSON
+		wantBody        string
+		wantErr         string
+	}{
+		{
+			name:       "unexpected status code",
+			statusCode: 500,
+			body:       "woa there",
+			wantErr:    "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name:       "body",
+			statusCode: 200,
+			body:       `{"value_one":"hi","value_two":42}`,
+			wantData:   structuredJSON{ValueOne: "hi", ValueTwo: 42},
+			wantBody:   `{"value_one":"hi","value_two":42}`,
+		},
+		{
+			name:       "trailing bytes are included",
+			statusCode: 200,
+			body:       `{"value_one":"hi"}` + "\n" + strings.Repeat(" ", 10000) + "\n",
+			wantData:   structuredJSON{ValueOne: "hi"},
+			wantBody:   `{"value_one":"hi"}` + "\n" + strings.Repeat(" ", 10000) + "\n",
+		},
+		{
+			name:            "decompressed body",
+			statusCode:      200,
+			contentEncoding: "gzip",
+			body:            gzipped(`{"value_two":42}`),
+			wantData:        structuredJSON{ValueTwo: 42},
+			wantBody:        `{"value_two":42}`,
+		},
+		{
+			name:       "over the limit",
+			statusCode: 200,
+			body:       `{"value_one":"hi"}` + strings.Repeat(" ", 100),
+			opts:       []httpparse.Option{httpparse.WithReadLimit(50)},
+			wantData:   structuredJSON{ValueOne: "hi"},
+			wantErr:    "The response body contained more than the limit of 50 bytes",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: test.statusCode,
+				Header:     http.Header{"Content-Encoding": {test.contentEncoding}},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			body, err := httpparse.JSONWithBody(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if got, want := string(body), test.wantBody; got != want {
+				t.Errorf("got body %q, wanted %q", got, want)
+			}
+		})
+	}
+}
+
+// TestWithTee tests that the body is written to the writer as it is
+// decoded.
+func TestWithTee(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(strings.NewReader(`<doc><value_one>hi</value_one></doc>`)),
+	}
+	var buf bytes.Buffer
+	var data structuredXML
+	if err := httpparse.XML(resp, []int{200}, &data, httpparse.WithTee(&buf)); err != nil {
+		t.Fatalf("got a non-nil error: %v", err)
+	}
+	if got, want := data.ValueOne, "hi"; got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	if got, want := buf.String(), `<doc><value_one>hi</value_one></doc>`; got != want {
+		t.Errorf("got body %q, wanted %q", got, want)
+	}
+}
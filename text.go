@@ -0,0 +1,33 @@
+package httpparse
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Text returns the http body as a string, for plain text responses.
+// It works just like RawBody, errors included, except that a single
+// trailing newline ("\n" or "\r\n") is trimmed off since it is almost
+// never wanted. Any other whitespace is left alone. A body in a charset
+// other than UTF-8, according to the Content-Type header, is
+// transcoded to UTF-8.
+func Text(resp *http.Response, wantStatuses []int, opts ...Option) (string, error) {
+	buf, err := RawBodyPooled(resp, wantStatuses, opts...)
+	if err != nil {
+		return "", err
+	}
+	defer buf.Release()
+	body, err := transcodeBytes(buf.Bytes(), resp)
+	if err != nil {
+		return "", err
+	}
+	return trimNewline(string(body)), nil
+}
+
+// trimNewline trims a single trailing "\n" or "\r\n" off of text.
+func trimNewline(text string) string {
+	if strings.HasSuffix(text, "\r\n") {
+		return text[:len(text)-2]
+	}
+	return strings.TrimSuffix(text, "\n")
+}
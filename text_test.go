@@ -0,0 +1,78 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestText tests that the body is returned as a string without its
+// trailing newline.
+func TestText(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		wantText string
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there\n")),
+			},
+			wantText: "",
+			wantErr:  "got status code 999 but wanted 200, body: woa there",
+		},
+		{
+			name: "no trailing newline",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there")),
+			},
+			wantText: "hello there",
+		},
+		{
+			name: "trailing newline",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there\n")),
+			},
+			wantText: "hello there",
+		},
+		{
+			name: "trailing carriage return and newline",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("hello there\r\n")),
+			},
+			wantText: "hello there",
+		},
+		{
+			name: "only a single newline is trimmed",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(" hello\nthere \n\n")),
+			},
+			wantText: " hello\nthere \n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text, err := httpparse.Text(test.resp, []int{200})
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := text, test.wantText; got != want {
+				t.Errorf("got text %q, wanted %q", got, want)
+			}
+		})
+	}
+}
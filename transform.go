@@ -0,0 +1,453 @@
+package httpparse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// needsRewrite reports whether any of the options require the JSON to
+// be rewritten before it gets unmarshalled.
+func (c *config) needsRewrite() bool {
+	return c.convertCase || c.localeNumbers
+}
+
+// needsValidation reports whether any of the options require the JSON
+// to be checked before it gets unmarshalled.
+func (c *config) needsValidation() bool {
+	return c.noDuplicateKeys || c.maxDepth > 0 || c.maxTokens > 0
+}
+
+// needsRawJSON reports whether any of the options require the JSON to
+// be massaged before it gets unmarshalled.
+func (c *config) needsRawJSON() bool {
+	return c.needsRewrite() || c.needsValidation() || c.reviver != nil
+}
+
+// decodeJSON decodes the JSON in r into v. An empty body, like that of
+// a 204 No Content, leaves v untouched and a leading byte order mark
+// is stripped. Most of the time that is just json.Decoder but some
+// options need to massage the JSON first: a reviver and the validating
+// options need a tokenizing pass over the JSON and the rewriting
+// options need to know the type of v, which means decoding into a
+// generic interface{}, rewriting it, then marshalling and unmarshalling
+// it again. That costs a fair bit more than decoding directly so it
+// only happens when one of those options is used.
+func decodeJSON(r io.Reader, v interface{}, c *config) error {
+	pooled := getReader(r)
+	defer putReader(pooled)
+	br := c.stripBOM(pooled)
+	if empty, err := emptyJSON(br); empty || err != nil {
+		return err
+	}
+	if c.envelope {
+		data, err := c.unwrapEnvelope(br)
+		if err != nil || data == nil {
+			return err
+		}
+		br.Reset(bytes.NewReader(data))
+	}
+	if !c.needsRawJSON() && c.jsonDecoder != nil {
+		return c.jsonDecoder(br, v)
+	}
+	dec := json.NewDecoder(br)
+	if !c.needsRawJSON() {
+		if c.strict {
+			dec.DisallowUnknownFields()
+		}
+		if c.useNumber {
+			dec.UseNumber()
+		}
+		return dec.Decode(v)
+	}
+	dec.UseNumber()
+	if c.needsValidation() {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if err := c.validate(raw); err != nil {
+			return err
+		}
+		dec = json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+	}
+	var data json.RawMessage
+	var err error
+	if c.reviver != nil {
+		data, err = revive(dec, "", c.reviver)
+		if len(data) == 0 {
+			data = json.RawMessage("null")
+		}
+	} else {
+		err = dec.Decode(&data)
+	}
+	if err != nil {
+		return err
+	}
+	if c.needsRewrite() {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var doc interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(c.rewrite(doc, reflect.TypeOf(v))); err != nil {
+			return err
+		}
+	}
+	if c.jsonDecoder != nil {
+		return c.jsonDecoder(bytes.NewReader(data), v)
+	}
+	dec = json.NewDecoder(bytes.NewReader(data))
+	if c.strict {
+		dec.DisallowUnknownFields()
+	}
+	if c.useNumber {
+		dec.UseNumber()
+	}
+	return dec.Decode(v)
+}
+
+// validate checks the JSON in data according to the validating options.
+func (c *config) validate(data json.RawMessage) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tokens := 0
+	return c.validateValue(dec, 1, &tokens)
+}
+
+// validateValue reads the next JSON value from dec, validating it and
+// its children. depth is how deeply nested the value is and tokens is
+// the number of tokens read so far.
+func (c *config) validateValue(dec *json.Decoder, depth int, tokens *int) error {
+	tok, err := c.validToken(dec, tokens)
+	if err != nil {
+		return err
+	}
+	if tok == json.Delim('{') || tok == json.Delim('[') {
+		if c.maxDepth > 0 && depth > c.maxDepth {
+			return fmt.Errorf("JSON is nested more than %d levels deep", c.maxDepth)
+		}
+	}
+	switch tok {
+	case json.Delim('{'):
+		seen := map[string]bool{}
+		for dec.More() {
+			tok, err := c.validToken(dec, tokens)
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			if c.noDuplicateKeys && seen[key] {
+				return fmt.Errorf("duplicate key %q", key)
+			}
+			seen[key] = true
+			if err := c.validateValue(dec, depth+1, tokens); err != nil {
+				return err
+			}
+		}
+		_, err = c.validToken(dec, tokens)
+	case json.Delim('['):
+		for dec.More() {
+			if err := c.validateValue(dec, depth+1, tokens); err != nil {
+				return err
+			}
+		}
+		_, err = c.validToken(dec, tokens)
+	}
+	return err
+}
+
+// validToken reads the next token from dec, counting it against the
+// token limit.
+func (c *config) validToken(dec *json.Decoder, tokens *int) (json.Token, error) {
+	if *tokens++; c.maxTokens > 0 && *tokens > c.maxTokens {
+		return nil, fmt.Errorf("JSON has more than %d tokens", c.maxTokens)
+	}
+	return dec.Token()
+}
+
+// emptyJSON reports whether br holds nothing but whitespace. Only the
+// whitespace gets consumed.
+func emptyJSON(br *bufio.Reader) (bool, error) {
+	for {
+		b, err := br.Peek(1)
+		if err == io.EOF {
+			return true, nil
+		} else if err != nil {
+			return false, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\n', '\r':
+			br.Discard(1)
+		default:
+			return false, nil
+		}
+	}
+}
+
+// revive reads the next JSON value from dec, reviving its children
+// before handing it to fn along with the key it was found under.
+func revive(dec *json.Decoder, key string, fn func(key string, value json.RawMessage) (json.RawMessage, error)) (json.RawMessage, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	switch tok {
+	case json.Delim('{'):
+		buf.WriteByte('{')
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			k := tok.(string)
+			val, err := revive(dec, k, fn)
+			if err != nil {
+				return nil, err
+			}
+			if len(val) == 0 {
+				continue
+			}
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+			kb, _ := json.Marshal(k)
+			buf.Write(kb)
+			buf.WriteByte(':')
+			buf.Write(val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('}')
+	case json.Delim('['):
+		buf.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			val, err := revive(dec, strconv.Itoa(i), fn)
+			if err != nil {
+				return nil, err
+			}
+			if len(val) == 0 {
+				val = json.RawMessage("null")
+			}
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.Write(val)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(']')
+	default:
+		b, err := json.Marshal(tok)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	val, err := fn(key, buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("reviver failed for key %q: %v", key, err)
+	}
+	return val, nil
+}
+
+// rewrite walks doc alongside t, the type doc will eventually be
+// unmarshalled into, and returns the rewritten doc.
+func (c *config) rewrite(doc interface{}, t reflect.Type) interface{} {
+	if t == nil {
+		return doc
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types which unmarshal themselves get the JSON as is.
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return doc
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return doc
+		}
+		return c.rewriteObject(obj, jsonFields(t))
+	case reflect.Map:
+		obj, ok := doc.(map[string]interface{})
+		if !ok {
+			return doc
+		}
+		res := make(map[string]interface{}, len(obj))
+		for k, v := range obj {
+			res[k] = c.rewrite(v, t.Elem())
+		}
+		return res
+	case reflect.Slice, reflect.Array:
+		arr, ok := doc.([]interface{})
+		if !ok {
+			return doc
+		}
+		res := make([]interface{}, len(arr))
+		for i, v := range arr {
+			res[i] = c.rewrite(v, t.Elem())
+		}
+		return res
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := doc.(string); ok && c.localeNumbers {
+			if n, ok := c.normalizeNumber(s); ok {
+				return json.Number(n)
+			}
+		}
+	}
+	return doc
+}
+
+// rewriteField rewrites the value of a struct field. It exists because
+// a field tagged with the ",string" option wants numbers to stay
+// inside of a string.
+func (c *config) rewriteField(v interface{}, f jsonField) interface{} {
+	_, wasString := v.(string)
+	v = c.rewrite(v, f.typ)
+	if n, ok := v.(json.Number); ok && wasString && f.quoted {
+		return string(n)
+	}
+	return v
+}
+
+// normalizeNumber turns a number written with locale specific
+// separators into one JSON understands. It reports false when the
+// result still is not a number.
+func (c *config) normalizeNumber(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if c.thousandsSep != 0 {
+		s = strings.Replace(s, string(c.thousandsSep), "", -1)
+	}
+	s = strings.Replace(s, string(c.decimalSep), ".", -1)
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return "", false
+	}
+	return s, true
+}
+
+// rewriteObject rewrites the members of a JSON object which will be
+// unmarshalled into a struct with the given fields.
+func (c *config) rewriteObject(obj map[string]interface{}, fields []jsonField) map[string]interface{} {
+	res := make(map[string]interface{}, len(obj))
+	matched := make(map[string]bool)
+	var unmatched []string
+	for k, v := range obj {
+		if f, ok := matchField(fields, k); ok {
+			res[k] = c.rewriteField(v, f)
+			matched[f.name] = true
+			continue
+		}
+		unmatched = append(unmatched, k)
+	}
+	sort.Strings(unmatched)
+	for _, k := range unmatched {
+		if !c.convertCase {
+			res[k] = obj[k]
+			continue
+		}
+		f, ok := convertField(fields, k)
+		// A key which already matched a field always wins over
+		// one that needed converting.
+		if !ok || matched[f.name] {
+			res[k] = obj[k]
+			continue
+		}
+		res[f.name] = c.rewriteField(obj[k], f)
+		matched[f.name] = true
+	}
+	return res
+}
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name   string
+	tagged bool
+	quoted bool
+	typ    reflect.Type
+}
+
+// jsonFields returns the fields of a struct which encoding/json would
+// unmarshal into, including those promoted from embedded structs.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		tagOpts := strings.Split(tag, ",")
+		name := tagOpts[0]
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(ft)...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		f := jsonField{name: name, tagged: name != "", typ: sf.Type}
+		for _, opt := range tagOpts[1:] {
+			f.quoted = f.quoted || opt == "string"
+		}
+		if !f.tagged {
+			f.name = sf.Name
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// matchField finds the field encoding/json would unmarshal key into.
+func matchField(fields []jsonField, key string) (jsonField, bool) {
+	for _, f := range fields {
+		if f.name == key {
+			return f, true
+		}
+	}
+	for _, f := range fields {
+		if strings.EqualFold(f.name, key) {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+// convertField finds the untagged field whose name matches key once
+// case, underscores and hyphens are ignored.
+func convertField(fields []jsonField, key string) (jsonField, bool) {
+	key = foldName(key)
+	for _, f := range fields {
+		if !f.tagged && foldName(f.name) == key {
+			return f, true
+		}
+	}
+	return jsonField{}, false
+}
+
+func foldName(s string) string {
+	s = strings.Replace(s, "_", "", -1)
+	s = strings.Replace(s, "-", "", -1)
+	return strings.ToLower(s)
+}
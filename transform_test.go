@@ -0,0 +1,586 @@
+package httpparse_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+type untaggedJSON struct {
+	UserName  string
+	AccountID int
+	Tagged    string `json:"tagged_value"`
+	Profile   struct {
+		FirstName string
+	}
+	Items []struct {
+		ItemCount int
+	}
+}
+
+// TestCaseConversion tests that JSON keys are matched to untagged
+// struct fields regardless of how they are cased.
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData untaggedJSON
+		wantErr  string
+	}{
+		{
+			name:     "snake case keys are not matched by default",
+			body:     `{"user_name":"bob","account_id":7}`,
+			opts:     nil,
+			wantData: untaggedJSON{},
+		},
+		{
+			name:     "snake case keys",
+			body:     `{"user_name":"bob","account_id":7}`,
+			opts:     []httpparse.Option{httpparse.WithCaseConversion()},
+			wantData: untaggedJSON{UserName: "bob", AccountID: 7},
+		},
+		{
+			name:     "camel and kebab case keys",
+			body:     `{"userName":"bob","account-id":7}`,
+			opts:     []httpparse.Option{httpparse.WithCaseConversion()},
+			wantData: untaggedJSON{UserName: "bob", AccountID: 7},
+		},
+		{
+			name: "nested objects and arrays",
+			body: `{"profile":{"first_name":"bob"},"items":[{"item_count":1},{"item_count":2}]}`,
+			opts: []httpparse.Option{httpparse.WithCaseConversion()},
+			wantData: func() untaggedJSON {
+				var d untaggedJSON
+				d.Profile.FirstName = "bob"
+				d.Items = []struct{ ItemCount int }{{1}, {2}}
+				return d
+			}(),
+		},
+		{
+			name:     "tagged fields are only matched by their tag",
+			body:     `{"tagged":"nope","tagged_value":"yep"}`,
+			opts:     []httpparse.Option{httpparse.WithCaseConversion()},
+			wantData: untaggedJSON{Tagged: "yep"},
+		},
+		{
+			name:     "keys which already match win",
+			body:     `{"user_name":"converted","username":"matched"}`,
+			opts:     []httpparse.Option{httpparse.WithCaseConversion()},
+			wantData: untaggedJSON{UserName: "matched"},
+		},
+		{
+			name:    "invalid JSON",
+			body:    `{"user_name":`,
+			opts:    []httpparse.Option{httpparse.WithCaseConversion()},
+			wantErr: "unmarshalling response body: unexpected EOF",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data untaggedJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; !reflect.DeepEqual(got, want) {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+type localeJSON struct {
+	Price  float64   `json:"price"`
+	Count  int       `json:"count"`
+	Quoted float64   `json:"quoted,string"`
+	Label  string    `json:"label"`
+	Prices []float64 `json:"prices"`
+}
+
+// TestLocaleNumbers tests that numbers written with locale specific
+// separators are unmarshalled into numeric fields.
+func TestLocaleNumbers(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData localeJSON
+		wantErr  string
+	}{
+		{
+			name:     "string numbers are rejected by default",
+			body:     `{"price":"3,14"}`,
+			opts:     nil,
+			wantData: localeJSON{},
+			wantErr:  "unmarshalling response body: json: cannot unmarshal string",
+		},
+		{
+			name:     "comma decimal separator",
+			body:     `{"price":"3,14","prices":["1,5",2.5],"count":7}`,
+			opts:     []httpparse.Option{httpparse.WithLocaleNumbers(',', 0)},
+			wantData: localeJSON{Price: 3.14, Prices: []float64{1.5, 2.5}, Count: 7},
+		},
+		{
+			name:     "thousands separators",
+			body:     `{"price":"-1.234.567,5","count":" 1.000 "}`,
+			opts:     []httpparse.Option{httpparse.WithLocaleNumbers(',', '.')},
+			wantData: localeJSON{Price: -1234567.5, Count: 1000},
+		},
+		{
+			name:     "fields tagged with the string option stay quoted",
+			body:     `{"quoted":"2,5"}`,
+			opts:     []httpparse.Option{httpparse.WithLocaleNumbers(',', 0)},
+			wantData: localeJSON{Quoted: 2.5},
+		},
+		{
+			name:     "non-numeric fields are left alone",
+			body:     `{"label":"3,14"}`,
+			opts:     []httpparse.Option{httpparse.WithLocaleNumbers(',', 0)},
+			wantData: localeJSON{Label: "3,14"},
+		},
+		{
+			name:     "strings which are still not numbers",
+			body:     `{"price":"three"}`,
+			opts:     []httpparse.Option{httpparse.WithLocaleNumbers(',', 0)},
+			wantData: localeJSON{},
+			wantErr:  "unmarshalling response body: json: cannot unmarshal string",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data localeJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; !reflect.DeepEqual(got, want) {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestReviver tests that the reviver gets to replace values before
+// they are unmarshalled.
+func TestReviver(t *testing.T) {
+	type revived struct {
+		ValueOne string          `json:"value_one"`
+		ValueTwo int             `json:"value_two"`
+		Secret   string          `json:"secret"`
+		List     []interface{}   `json:"list"`
+		Nested   *structuredJSON `json:"nested"`
+	}
+	tests := []struct {
+		name     string
+		body     string
+		reviver  func(key string, value json.RawMessage) (json.RawMessage, error)
+		wantData revived
+		wantKeys []string
+		wantErr  string
+	}{
+		{
+			name: "values are visited depth first",
+			body: `{"value_one":"a","list":[1,"b"],"nested":{"value_two":2}}`,
+			reviver: func(key string, value json.RawMessage) (json.RawMessage, error) {
+				return value, nil
+			},
+			wantData: revived{ValueOne: "a", List: []interface{}{1.0, "b"}, Nested: &structuredJSON{ValueTwo: 2}},
+			wantKeys: []string{"value_one", "0", "1", "list", "value_two", "nested", ""},
+		},
+		{
+			name: "values are replaced and removed",
+			body: `{"value_one":"hello","value_two":1,"secret":"hunter2","nested":{"value_one":"there"},"list":[1,2]}`,
+			reviver: func(key string, value json.RawMessage) (json.RawMessage, error) {
+				switch key {
+				case "value_one":
+					return json.RawMessage(strings.ToUpper(string(value))), nil
+				case "value_two":
+					return json.RawMessage("42"), nil
+				case "secret":
+					return json.RawMessage(`"REDACTED"`), nil
+				case "1", "nested":
+					return nil, nil
+				}
+				return value, nil
+			},
+			wantData: revived{ValueOne: "HELLO", ValueTwo: 42, Secret: "REDACTED", List: []interface{}{1.0, nil}},
+		},
+		{
+			name: "the top level value is replaced",
+			body: `{"value_one":"hello"}`,
+			reviver: func(key string, value json.RawMessage) (json.RawMessage, error) {
+				if key == "" {
+					return json.RawMessage(`{"value_one":"replaced"}`), nil
+				}
+				return value, nil
+			},
+			wantData: revived{ValueOne: "replaced"},
+		},
+		{
+			name: "reviver fails",
+			body: `{"value_one":"hello"}`,
+			reviver: func(key string, value json.RawMessage) (json.RawMessage, error) {
+				return nil, errors.New("cannot decrypt")
+			},
+			wantErr: `unmarshalling response body: reviver failed for key "value_one": cannot decrypt`,
+		},
+		{
+			name: "invalid JSON",
+			body: `lats`,
+			reviver: func(key string, value json.RawMessage) (json.RawMessage, error) {
+				return value, nil
+			},
+			wantErr: "unmarshalling response body: invalid character 'l'",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var keys []string
+			reviver := func(key string, value json.RawMessage) (json.RawMessage, error) {
+				keys = append(keys, key)
+				return test.reviver(key, value)
+			}
+			var data revived
+			err := httpparse.JSON(resp, []int{200}, &data, httpparse.WithReviver(reviver))
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; !reflect.DeepEqual(got, want) {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if test.wantKeys != nil && !reflect.DeepEqual(keys, test.wantKeys) {
+				t.Errorf("got keys %q, wanted %q", keys, test.wantKeys)
+			}
+		})
+	}
+}
+
+// TestStrictJSON tests that unknown fields are only an error when
+// asked for.
+func TestStrictJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "unknown fields are dropped by default",
+			body:     `{"value_one":"hi","value_three":3}`,
+			opts:     nil,
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:     "known fields are fine",
+			body:     `{"value_one":"hi","value_two":2}`,
+			opts:     []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 2},
+		},
+		{
+			name:     "unknown fields",
+			body:     `{"value_one":"hi","value_three":3}`,
+			opts:     []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData: structuredJSON{ValueOne: "hi"},
+			wantErr:  `unmarshalling response body: json: unknown field "value_three"`,
+		},
+		{
+			name:     "unknown fields after reviving",
+			body:     `{"value_one":"hi","value_three":3}`,
+			opts:     []httpparse.Option{httpparse.WithStrictJSON(), httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) { return value, nil })},
+			wantData: structuredJSON{ValueOne: "hi"},
+			wantErr:  `unmarshalling response body: json: unknown field "value_three"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestJSONDecoder tests that JSON bodies are unmarshalled with the JSON
+// decoder when one is given.
+func TestJSONDecoder(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "decoded with the JSON decoder",
+			body:     `{"value_one":"hi","value_two":2}`,
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 2},
+		},
+		{
+			name:     "decoded with the JSON decoder after reviving",
+			body:     `{"value_one":"hi","value_two":2}`,
+			opts:     []httpparse.Option{httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) { return value, nil })},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 2},
+		},
+		{
+			name:     "strict is up to the JSON decoder",
+			body:     `{"value_one":"hi","value_three":3}`,
+			opts:     []httpparse.Option{httpparse.WithStrictJSON()},
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:    "JSON decoder error",
+			body:    `{"value_one":"fail"}`,
+			wantErr: "unmarshalling response body: engine failed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			called := false
+			engine := httpparse.WithJSONDecoder(func(r io.Reader, v interface{}) error {
+				called = true
+				b, err := ioutil.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(string(b), "fail") {
+					return errors.New("engine failed")
+				}
+				return json.Unmarshal(b, v)
+			})
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, append(test.opts, engine)...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+			if !called {
+				t.Errorf("the JSON decoder was not called")
+			}
+		})
+	}
+}
+
+// TestUseNumber tests that numbers decoded into an interface{} are only
+// kept as json.Number when asked for.
+func TestUseNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []httpparse.Option
+		wantData map[string]interface{}
+	}{
+		{
+			name:     "float64 by default",
+			opts:     nil,
+			wantData: map[string]interface{}{"id": float64(1234567890123456789)},
+		},
+		{
+			name:     "json.Number",
+			opts:     []httpparse.Option{httpparse.WithUseNumber()},
+			wantData: map[string]interface{}{"id": json.Number("1234567890123456789")},
+		},
+		{
+			name:     "json.Number after reviving",
+			opts:     []httpparse.Option{httpparse.WithUseNumber(), httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) { return value, nil })},
+			wantData: map[string]interface{}{"id": json.Number("1234567890123456789")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"id":1234567890123456789}`)),
+			}
+			var data map[string]interface{}
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			}
+			if got, want := data, test.wantData; !reflect.DeepEqual(got, want) {
+				t.Errorf("got data %#v, wanted %#v", got, want)
+			}
+		})
+	}
+}
+
+// TestNoDuplicateKeys tests that duplicate keys are only an error when
+// asked for.
+func TestNoDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "the last duplicate wins by default",
+			body:     `{"value_one":"hi","value_one":"bye"}`,
+			opts:     nil,
+			wantData: structuredJSON{ValueOne: "bye"},
+		},
+		{
+			name:     "no duplicates",
+			body:     `{"value_one":"hi","value_two":2}`,
+			opts:     []httpparse.Option{httpparse.WithNoDuplicateKeys()},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 2},
+		},
+		{
+			name:     "same key in different objects",
+			body:     `{"value_one":"hi","nested":[{"value_one":1},{"value_one":2}]}`,
+			opts:     []httpparse.Option{httpparse.WithNoDuplicateKeys()},
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:    "duplicate keys",
+			body:    `{"value_one":"hi","value_one":"bye"}`,
+			opts:    []httpparse.Option{httpparse.WithNoDuplicateKeys()},
+			wantErr: `unmarshalling response body: duplicate key "value_one"`,
+		},
+		{
+			name:    "nested duplicate keys",
+			body:    `{"value_one":"hi","nested":[{"a":1,"b":2,"a":3}]}`,
+			opts:    []httpparse.Option{httpparse.WithNoDuplicateKeys()},
+			wantErr: `unmarshalling response body: duplicate key "a"`,
+		},
+		{
+			name:    "duplicate keys with a reviver",
+			body:    `{"value_one":"hi","value_one":"bye"}`,
+			opts:    []httpparse.Option{httpparse.WithNoDuplicateKeys(), httpparse.WithReviver(func(key string, value json.RawMessage) (json.RawMessage, error) { return value, nil })},
+			wantErr: `unmarshalling response body: duplicate key "value_one"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestJSONLimits tests that JSON which is too deep or has too many
+// tokens is an error.
+func TestJSONLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		opts     []httpparse.Option
+		wantData structuredJSON
+		wantErr  string
+	}{
+		{
+			name:     "no limits by default",
+			body:     `{"value_one":"hi","nested":[[[[{}]]]]}`,
+			opts:     nil,
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:     "exactly the maximum depth",
+			body:     `{"value_one":"hi","nested":[[{}]]}`,
+			opts:     []httpparse.Option{httpparse.WithMaxDepth(4)},
+			wantData: structuredJSON{ValueOne: "hi"},
+		},
+		{
+			name:    "too deep",
+			body:    `{"value_one":"hi","nested":[[[{}]]]}`,
+			opts:    []httpparse.Option{httpparse.WithMaxDepth(4)},
+			wantErr: "unmarshalling response body: JSON is nested more than 4 levels deep",
+		},
+		{
+			name:     "exactly the maximum tokens",
+			body:     `{"value_one":"hi","value_two":2}`,
+			opts:     []httpparse.Option{httpparse.WithMaxTokens(6)},
+			wantData: structuredJSON{ValueOne: "hi", ValueTwo: 2},
+		},
+		{
+			name:    "too many tokens",
+			body:    `{"value_one":"hi","value_two":2,"nested":[1,2,3]}`,
+			opts:    []httpparse.Option{httpparse.WithMaxTokens(6)},
+			wantErr: "unmarshalling response body: JSON has more than 6 tokens",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			var data structuredJSON
+			err := httpparse.JSON(resp, []int{200}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
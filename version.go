@@ -0,0 +1,44 @@
+package httpparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// JSONExpectNewer parses a http response who's body contains JSON
+// just like JSON does and then checks that the version number found
+// at versionPath (a dotted path like "meta.version") is greater than
+// previous. This is meant for read-after-write consistency checks
+// where getting back an older version of a resource means the read
+// was served by a stale replica. The version may be a JSON number or
+// a string containing an integer. Options like WithEnvelope only change
+// what ends up in v, versionPath is always followed from the top of the
+// body.
+func JSONExpectNewer(resp *http.Response, wantStatus int, v interface{}, versionPath string, previous int64, opts ...Option) error {
+	body, err := JSONWithBody(resp, []int{wantStatus}, v, opts...)
+	if err != nil {
+		return err
+	}
+	val, err := lookupPath(body, versionPath)
+	if err != nil {
+		return err
+	}
+	var version int64
+	switch val := val.(type) {
+	case json.Number:
+		version, err = val.Int64()
+	case string:
+		version, err = strconv.ParseInt(val, 10, 64)
+	default:
+		err = fmt.Errorf("got %v", val)
+	}
+	if err != nil {
+		return fmt.Errorf("response version at path %q is not an integer: %v", versionPath, err)
+	}
+	if version <= previous {
+		return fmt.Errorf("response version %d is not newer than %d (possible stale read)", version, previous)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestJSONExpectNewer tests that a response is only accepted when its
+// version is newer than the previous one.
+func TestJSONExpectNewer(t *testing.T) {
+	tests := []struct {
+		name        string
+		resp        *http.Response
+		versionPath string
+		opts        []httpparse.Option
+		wantData    structuredJSON
+		wantErr     string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			versionPath: "version",
+			wantData:    structuredJSON{},
+			wantErr:     "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("lats")),
+			},
+			versionPath: "version",
+			wantData:    structuredJSON{},
+			wantErr:     "unmarshalling response body: invalid character 'l'",
+		},
+		{
+			name: "options are applied",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi","meta":{"version":11}}`)),
+			},
+			versionPath: "meta.version",
+			opts:        []httpparse.Option{httpparse.WithReadLimit(10)},
+			wantData:    structuredJSON{},
+			wantErr:     "more than the limit of 10 bytes",
+		},
+		{
+			name: "version is missing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42}`)),
+			},
+			versionPath: "meta.version",
+			wantData:    structuredJSON{ValueTwo: 42},
+			wantErr:     `response body has no value at path "meta.version"`,
+		},
+		{
+			name: "version is not an integer",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"meta":{"version":"v2"}}`)),
+			},
+			versionPath: "meta.version",
+			wantData:    structuredJSON{},
+			wantErr:     `response version at path "meta.version" is not an integer`,
+		},
+		{
+			name: "version is the same",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_two":42,"meta":{"version":10}}`)),
+			},
+			versionPath: "meta.version",
+			wantData:    structuredJSON{ValueTwo: 42},
+			wantErr:     "response version 10 is not newer than 10 (possible stale read)",
+		},
+		{
+			name: "version is older",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"versions":[{"n":"9"}]}`)),
+			},
+			versionPath: "versions.0.n",
+			wantData:    structuredJSON{},
+			wantErr:     "response version 9 is not newer than 10 (possible stale read)",
+		},
+		{
+			name: "version is newer",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(`{"value_one":"hi","meta":{"version":11}}`)),
+			},
+			versionPath: "meta.version",
+			wantData:    structuredJSON{ValueOne: "hi"},
+			wantErr:     "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredJSON
+			err := httpparse.JSONExpectNewer(test.resp, 200, &data, test.versionPath, 10, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package httpparse
+
+import (
+	"io"
+	"net/http"
+)
+
+// XML parses a http response who's body contains XML and closes the
+// response body. It works just like JSON does, including its limits,
+// charset handling and error messages, except that it uses
+// encoding/xml. A body with no charset in its Content-Type is
+// transcoded according to its XML declaration instead, e.g. <?xml
+// version="1.0" encoding="ISO-8859-1"?>. Options which only make sense
+// for JSON are ignored.
+func XML(resp *http.Response, wantStatuses []int, v interface{}, opts ...Option) error {
+	opts = append(opts[:len(opts):len(opts)], func(c *config) { c.transcode = true })
+	return parseBody(resp, wantStatuses, opts, func(r io.Reader, c *config) error {
+		return decodeXML(r, v, c)
+	})
+}
+
+// decodeXML decodes the XML in r into v.
+func decodeXML(r io.Reader, v interface{}, c *config) error {
+	return xmlDecoder(r, c.transcoded).Decode(v)
+}
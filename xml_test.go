@@ -0,0 +1,99 @@
+package httpparse_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+type structuredXML struct {
+	ValueOne string `xml:"value_one"`
+	ValueTwo int    `xml:"value_two"`
+}
+
+// TestParseXMLResponse tests that parsing a http response with an XML
+// body returns an error when expected and unmarshals the raw XML into
+// the provided value.
+func TestParseXMLResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		resp           *http.Response
+		expectStatuses []int
+		wantData       structuredXML
+		wantErr        string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			expectStatuses: []int{200},
+			wantData:       structuredXML{},
+			wantErr:        "got status code 999 but wanted 200, body: woa there",
+		},
+		{
+			name: "unexpected response status code and error reading response body",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       errReadCloser{readErr: errors.New("some read err")},
+			},
+			expectStatuses: []int{200},
+			wantData:       structuredXML{},
+			wantErr:        "got status code 999 but wanted 200, also an error occurred when reading the response body: some read err",
+		},
+		{
+			name: "unexpected response status code did not read all of response body",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader(strings.Repeat("z", 1<<20+1))),
+			},
+			expectStatuses: []int{200},
+			wantData:       structuredXML{},
+			wantErr:        "got status code 999 but wanted 200, the first 512 bytes of the response body are: " + strings.Repeat("z", 512) + "...",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader(`<data><value_one>hi</data>`)),
+			},
+			expectStatuses: []int{400},
+			wantData:       structuredXML{},
+			wantErr:        "unmarshalling response body: XML syntax error",
+		},
+		{
+			name: "got the structured data",
+			resp: &http.Response{
+				StatusCode: 400,
+				Body:       ioutil.NopCloser(strings.NewReader(`<data><value_one>hello there</value_one><value_two>42</value_two></data>`)),
+			},
+			expectStatuses: []int{400},
+			wantData: structuredXML{
+				ValueOne: "hello there",
+				ValueTwo: 42,
+			},
+			wantErr: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredXML
+			err := httpparse.XML(test.resp, test.expectStatuses, &data)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package httpparse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// XMLQuery pulls a handful of values out of a http response who's body
+// contains XML without having to define structs for the whole
+// document. queries maps a name of your choosing to a path of slash
+// separated element names starting at the root element, e.g.
+// "envelope/body/user/name" (a leading slash is allowed). The result
+// maps each name to the text content of the first element matching
+// its path, which includes the text of any child elements and has
+// surrounding whitespace trimmed. Namespaces are ignored. It is an
+// error for a path to match nothing.
+func XMLQuery(resp *http.Response, wantStatus int, queries map[string]string) (map[string]string, error) {
+	body, err := RawBody(resp, []int{wantStatus})
+	if err != nil {
+		return nil, err
+	}
+	type match struct {
+		depth int
+		text  strings.Builder
+	}
+	// The body is transcoded like XML does it, according to the
+	// Content-Type's charset or else the XML declaration.
+	body, err = transcodeBytes(body, resp)
+	if err != nil {
+		return nil, err
+	}
+	active := map[string]*match{}
+	found := map[string]string{}
+	var path []string
+	dec := xmlDecoder(bytes.NewReader(body), contentTypeCharset(resp) != "")
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, &DecodeError{Err: err, doing: "parsing XML response body"}
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			path = append(path, tok.Name.Local)
+			current := strings.Join(path, "/")
+			for name, query := range queries {
+				_, isActive := active[name]
+				_, isFound := found[name]
+				if !isActive && !isFound && strings.TrimPrefix(query, "/") == current {
+					active[name] = &match{depth: len(path)}
+				}
+			}
+		case xml.CharData:
+			for _, m := range active {
+				m.text.Write(tok)
+			}
+		case xml.EndElement:
+			for name, m := range active {
+				if m.depth == len(path) {
+					found[name] = strings.TrimSpace(m.text.String())
+					delete(active, name)
+				}
+			}
+			path = path[:len(path)-1]
+		}
+	}
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := found[name]; !ok {
+			return nil, fmt.Errorf("no element in the response body matches the path %q", queries[name])
+		}
+	}
+	return found, nil
+}
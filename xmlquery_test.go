@@ -0,0 +1,121 @@
+package httpparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+)
+
+// TestXMLQuery tests that values are pulled out of an XML response
+// body by their paths.
+func TestXMLQuery(t *testing.T) {
+	const doc = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <user id="7">
+      <name> Bob </name>
+      <address><city>Springfield</city> <zip>12345</zip></address>
+    </user>
+    <user><name>Alice</name></user>
+  </soap:Body>
+</soap:Envelope>`
+	tests := []struct {
+		name       string
+		resp       *http.Response
+		queries    map[string]string
+		wantResult map[string]string
+		wantErr    string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 500,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			queries: map[string]string{"name": "Envelope/Body/user/name"},
+			wantErr: "got status code 500 but wanted 200, body: woa there",
+		},
+		{
+			name: "malformed XML",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("<a><b></a>")),
+			},
+			queries: map[string]string{"b": "a/b"},
+			wantErr: "parsing XML response body: XML syntax error",
+		},
+		{
+			name: "path matches nothing",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(doc)),
+			},
+			queries: map[string]string{"phone": "Envelope/Body/user/phone"},
+			wantErr: `no element in the response body matches the path "Envelope/Body/user/phone"`,
+		},
+		{
+			name: "first missing path by name",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(doc)),
+			},
+			queries: map[string]string{"b": "Envelope/b", "a": "Envelope/a", "c": "Envelope/c"},
+			wantErr: `no element in the response body matches the path "Envelope/a"`,
+		},
+		{
+			name: "encoding from the XML declaration",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a><b>caf\xe9</b></a>")),
+			},
+			queries:    map[string]string{"b": "a/b"},
+			wantResult: map[string]string{"b": "caf\u00e9"},
+		},
+		{
+			name: "charset from the Content-Type",
+			resp: &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"Content-Type": {"application/xml; charset=iso-8859-1"}},
+				Body:       ioutil.NopCloser(strings.NewReader("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a><b>caf\xe9</b></a>")),
+			},
+			queries:    map[string]string{"b": "a/b"},
+			wantResult: map[string]string{"b": "caf\u00e9"},
+		},
+		{
+			name: "values are extracted",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader(doc)),
+			},
+			queries: map[string]string{
+				"name":    "/Envelope/Body/user/name",
+				"city":    "Envelope/Body/user/address/city",
+				"address": "Envelope/Body/user/address",
+			},
+			wantResult: map[string]string{
+				"name":    "Bob",
+				"city":    "Springfield",
+				"address": "Springfield 12345",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := httpparse.XMLQuery(test.resp, 200, test.queries)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := result, test.wantResult; !reflect.DeepEqual(got, want) {
+				t.Errorf("got result %v, wanted %v", got, want)
+			}
+		})
+	}
+}
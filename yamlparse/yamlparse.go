@@ -0,0 +1,27 @@
+// Package yamlparse parses http responses who's body contains YAML. It
+// lives apart from httpparse so that only those who need YAML depend on
+// a YAML library.
+package yamlparse
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/lag13/httpparse"
+	"gopkg.in/yaml.v3"
+)
+
+// YAML parses a http response who's body contains YAML (e.g.
+// application/yaml) and closes the response body. It works just like
+// httpparse.JSON does, including the status code checks, read limit,
+// error messages and options, except that it uses gopkg.in/yaml.v3. As
+// with JSON, an empty wantStatuses accepts no status code, which is
+// only useful along with httpparse.WithStatusMatcher.
+func YAML(resp *http.Response, wantStatuses []int, v interface{}, opts ...httpparse.Option) error {
+	opts = append(opts[:len(opts):len(opts)], httpparse.WithExpectedStatuses(wantStatuses...), httpparse.WithDecoder(decode))
+	return httpparse.NewParser(opts...).Decode(resp, v)
+}
+
+func decode(r io.Reader, v interface{}) error {
+	return yaml.NewDecoder(r).Decode(v)
+}
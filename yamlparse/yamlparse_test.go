@@ -0,0 +1,121 @@
+package yamlparse_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/lag13/httpparse"
+	"github.com/lag13/httpparse/yamlparse"
+)
+
+type structuredYAML struct {
+	ValueOne string `yaml:"value_one"`
+	ValueTwo int    `yaml:"value_two"`
+}
+
+// TestYAML tests that parsing a http response with a YAML body returns
+// an error when expected and unmarshals the YAML otherwise.
+func TestYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     *http.Response
+		opts     []httpparse.Option
+		wantData structuredYAML
+		wantErr  string
+	}{
+		{
+			name: "unexpected response status code",
+			resp: &http.Response{
+				StatusCode: 999,
+				Body:       ioutil.NopCloser(strings.NewReader("woa there")),
+			},
+			wantData: structuredYAML{},
+			wantErr:  "got status code 999 but wanted one of [200 201], body: woa there",
+		},
+		{
+			name: "error when unmarshalling response body",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("value_two: [nope")),
+			},
+			wantData: structuredYAML{},
+			wantErr:  "unmarshalling response body: yaml: line 1",
+		},
+		{
+			name: "response body exceeded the limit",
+			resp: &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("value_one: hello there\n")),
+			},
+			opts:     []httpparse.Option{httpparse.WithReadLimit(10)},
+			wantData: structuredYAML{},
+			wantErr:  "The response body contained more than the limit of 10 bytes",
+		},
+		{
+			name: "got the structured data",
+			resp: &http.Response{
+				StatusCode: 201,
+				Body:       ioutil.NopCloser(strings.NewReader("value_one: hello there\nvalue_two: 42\n")),
+			},
+			wantData: structuredYAML{ValueOne: "hello there", ValueTwo: 42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data structuredYAML
+			err := yamlparse.YAML(test.resp, []int{200, 201}, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestYAMLNoStatuses tests that no wanted status codes means none are
+// accepted, not that 200 is.
+func TestYAMLNoStatuses(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []httpparse.Option
+		wantData structuredYAML
+		wantErr  string
+	}{
+		{
+			name:    "no status code accepted",
+			wantErr: "got unexpected status code 200, body: value_two: 42",
+		},
+		{
+			name:     "status code accepted by the matcher",
+			opts:     []httpparse.Option{httpparse.WithStatusMatcher(httpparse.Status2xx)},
+			wantData: structuredYAML{ValueTwo: 42},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(strings.NewReader("value_two: 42\n")),
+			}
+			var data structuredYAML
+			err := yamlparse.YAML(resp, nil, &data, test.opts...)
+
+			if test.wantErr == "" && err != nil {
+				t.Errorf("got a non-nil error: %v", err)
+			} else if got, want := fmt.Sprintf("%v", err), test.wantErr; want != "" && !strings.Contains(got, want) {
+				t.Errorf("got error message: %s, wanted message to contain the string: %s", got, want)
+			}
+			if got, want := data, test.wantData; got != want {
+				t.Errorf("got data %+v, wanted %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Package zstdparse teaches httpparse to decompress response bodies
+// with a Content-Encoding of zstd. Import it for its side effect:
+//
+//	import _ "github.com/lag13/httpparse/zstdparse"
+//
+// It lives apart from httpparse so that only those who need Zstandard
+// depend on a Zstandard library.
+package zstdparse
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/lag13/httpparse"
+)
+
+// maxWindow is the largest window a zstd coded body may use. RFC 9659
+// limits the zstd content coding to 8 MB windows, anything bigger only
+// serves to make us allocate more memory.
+const maxWindow = 8 << 20
+
+func init() {
+	httpparse.RegisterDecompressor("zstd", Decompress)
+}
+
+// Decompress is a httpparse.DecompressFunc for Zstandard, it uses
+// github.com/klauspost/compress/zstd. Like every other body the
+// decompressed one is subject to the read limit.
+func Decompress(r io.Reader) (io.Reader, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxWindow))
+	if err != nil {
+		return nil, err
+	}
+	return &decoder{d: d}, nil
+}
+
+// decoder releases the resources of a zstd.Decoder once it is done
+// reading since nothing else will.
+type decoder struct {
+	d *zstd.Decoder
+}
+
+func (d *decoder) Read(b []byte) (int, error) {
+	if d.d == nil {
+		return 0, io.EOF
+	}
+	n, err := d.d.Read(b)
+	if err != nil {
+		d.d.Close()
+		d.d = nil
+	}
+	return n, err
+}